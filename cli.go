@@ -0,0 +1,518 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	exitEqual     = 0
+	exitDifferent = 1
+	exitError     = 2
+)
+
+// errDifferent is returned by commands that found different statuses, so that
+// the process exits with exitDifferent like diff(1).
+var errDifferent = errors.New("status is different")
+
+// cli holds the flags shared by all commands.
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	kubeconfig string
+	context    string
+	namespace  string
+	output     string
+	policyFile string
+	overrides  optionFlags
+	watchFlags watchFlags
+
+	registry         *Registry
+	client           dynamic.Interface
+	mapper           meta.RESTMapper
+	defaultNamespace string
+	clock            clock.PassiveClock
+}
+
+type command struct {
+	name  string
+	usage string
+	short string
+	flags func(c *cli, fs *pflag.FlagSet)
+	run   func(c *cli, args []string) error
+}
+
+var commands = []command{
+	{
+		name:  "compare",
+		usage: "compare (RESOURCE/NAME | FILE | DIR) (RESOURCE/NAME | FILE | DIR)",
+		short: "Compare the status of two objects or snapshots and print the differing fields",
+		flags: (*cli).addOptionFlags,
+		run:   (*cli).compare,
+	},
+	{
+		name:  "hash",
+		usage: "hash (RESOURCE/NAME | FILE)...",
+		short: "Print the status hash of objects or manifests",
+		flags: (*cli).addOptionFlags,
+		run:   (*cli).hash,
+	},
+	{
+		name:  "explain",
+		usage: "explain (RESOURCE/NAME | FILE)",
+		short: "Print the comparison policy of an object and whether its status is stale",
+		run:   (*cli).explain,
+	},
+	{
+		name:  "watch",
+		usage: "watch (RESOURCE | RESOURCE/NAME)",
+		short: "Watch objects and print the updates that changed their status",
+		flags: (*cli).addWatchFlags,
+		run:   (*cli).watch,
+	},
+}
+
+// run runs the command in args and returns the exit code of the process.
+func (c *cli) run(args []string) int {
+	stderr := c.stderr
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		c.usage()
+		if len(args) == 0 {
+			return exitError
+		}
+		return exitEqual
+	}
+
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == args[0] {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(stderr, "unknown command %q\n\n", args[0])
+		c.usage()
+		return exitError
+	}
+
+	fs := pflag.NewFlagSet(cmd.name, pflag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "%s\n\nUsage:\n  status-equality-check %s [flags]\n\nFlags:\n%s", cmd.short, cmd.usage, fs.FlagUsages())
+	}
+	c.addFlags(fs)
+	if cmd.flags != nil {
+		cmd.flags(c, fs)
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return exitEqual
+		}
+		return exitError
+	}
+
+	if err := cmd.run(c, fs.Args()); err != nil {
+		if errors.Is(err, errDifferent) {
+			return exitDifferent
+		}
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return exitError
+	}
+	return exitEqual
+}
+
+func (c *cli) usage() {
+	fmt.Fprintln(c.stderr, "status-equality-check compares the status of Kubernetes objects.\n\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(c.stderr, "  %-10s %s\n", cmd.name, cmd.short)
+	}
+	fmt.Fprintln(c.stderr, "\nUse \"status-equality-check COMMAND --help\" for the flags of a command.")
+}
+
+func (c *cli) addFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&c.kubeconfig, "kubeconfig", "", "Path to the kubeconfig file. Defaults to $KUBECONFIG or ~/.kube/config")
+	fs.StringVar(&c.context, "context", "", "Name of the kubeconfig context to use")
+	fs.StringVarP(&c.namespace, "namespace", "n", "", "Namespace of the objects. Defaults to the namespace of the context")
+	fs.StringVarP(&c.output, "output", "o", "text", "Output format, one of text, json or yaml")
+	fs.StringVar(&c.policyFile, "policy", "", "Path to a PolicyConfig file. Defaults to the built-in policies")
+
+	klogFlags := flag.NewFlagSet("klog", flag.ContinueOnError)
+	klog.InitFlags(klogFlags)
+	fs.AddGoFlag(klogFlags.Lookup("v"))
+}
+
+// policies returns the Registry of the --policy flag.
+func (c *cli) policies() (*Registry, error) {
+	if c.registry != nil {
+		return c.registry, nil
+	}
+	r := NewDefaultRegistry(nil)
+	if c.policyFile != "" {
+		config, err := LoadPolicyConfig(c.policyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Apply(r)
+	}
+	c.registry = r
+	return r, nil
+}
+
+// options returns the policy of the compared objects with the option flags applied.
+func (c *cli) options(old, new interface{}) (EqualOptions, error) {
+	r, err := c.policies()
+	if err != nil {
+		return EqualOptions{}, err
+	}
+	opts, err := r.OptionsFor(old, new)
+	if err != nil {
+		return EqualOptions{}, err
+	}
+	return c.overrides.apply(opts)
+}
+
+// connect creates the clients of the cluster selected by the kubeconfig flags.
+func (c *cli) connect() error {
+	if c.client != nil {
+		return nil
+	}
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = c.kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: c.context}
+	loader := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides)
+
+	config, err := loader.ClientConfig()
+	if err != nil {
+		return fmt.Errorf("could not get Kubernetes config: %w", err)
+	}
+	ns, _, err := loader.Namespace()
+	if err != nil {
+		return err
+	}
+	return c.setClients(config, ns)
+}
+
+func (c *cli) setClients(config *rest.Config, namespace string) error {
+	dc, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return err
+	}
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	cached := memory.NewMemCacheClient(dc)
+	c.mapper = restmapper.NewShortcutExpander(restmapper.NewDeferredDiscoveryRESTMapper(cached), cached)
+	c.client = client
+	c.defaultNamespace = namespace
+	return nil
+}
+
+// resource returns the client and mapping of a resource argument, eg, deploy, deployments.apps or deployments.v1.apps
+func (c *cli) resource(arg string) (dynamic.ResourceInterface, *meta.RESTMapping, error) {
+	if err := c.connect(); err != nil {
+		return nil, nil, err
+	}
+	gvr, gr := schema.ParseResourceArg(arg)
+	var err error
+	var gvk schema.GroupVersionKind
+	if gvr != nil {
+		gvk, err = c.mapper.KindFor(*gvr)
+	}
+	if gvr == nil || err != nil {
+		gvk, err = c.mapper.KindFor(gr.WithVersion(""))
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	mapping, err := c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, nil, err
+	}
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return c.client.Resource(mapping.Resource), mapping, nil
+	}
+	ns := c.namespace
+	if ns == "" {
+		ns = c.defaultNamespace
+	}
+	return c.client.Resource(mapping.Resource).Namespace(ns), mapping, nil
+}
+
+// get returns the object of a RESOURCE/NAME argument.
+func (c *cli) get(ctx context.Context, arg string) (*unstructured.Unstructured, error) {
+	parts := strings.SplitN(arg, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid object %q, expected RESOURCE/NAME", arg)
+	}
+	ri, _, err := c.resource(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	return ri.Get(ctx, parts[1], metav1.GetOptions{})
+}
+
+// object returns the object of a RESOURCE/NAME or manifest file argument.
+func (c *cli) object(ctx context.Context, arg string) (*unstructured.Unstructured, error) {
+	objects, err := c.objects(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) != 1 {
+		return nil, fmt.Errorf("%s: expected 1 object, got %d", arg, len(objects))
+	}
+	return objects[0], nil
+}
+
+// objects returns the object of a RESOURCE/NAME argument, or the objects of a
+// manifest file or directory argument.
+func (c *cli) objects(ctx context.Context, arg string) ([]*unstructured.Unstructured, error) {
+	if !isManifestArg(arg) {
+		obj, err := c.get(ctx, arg)
+		if err != nil {
+			return nil, err
+		}
+		return []*unstructured.Unstructured{obj}, nil
+	}
+	return loadManifests(arg, c.stdin)
+}
+
+// print writes v as json or yaml, or calls text for the text output.
+func (c *cli) print(v interface{}, text func(w io.Writer)) error {
+	switch c.output {
+	case "text", "":
+		text(c.stdout)
+		return nil
+	case "json":
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(c.stdout, string(data))
+		return err
+	case "yaml":
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = c.stdout.Write(data)
+		return err
+	}
+	return fmt.Errorf("unknown output format %q, expected text, json or yaml", c.output)
+}
+
+// CompareResult is the json and yaml output of the compare command.
+type CompareResult struct {
+	Old         string `json:"old"`
+	New         string `json:"new"`
+	Equal       bool   `json:"equal"`
+	*StatusDiff `json:",inline"`
+}
+
+func (c *cli) compare(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("compare takes 2 objects, got %d", len(args))
+	}
+	ctx := context.Background()
+	oldObjects, err := c.objects(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	newObjects, err := c.objects(ctx, args[1])
+	if err != nil {
+		return err
+	}
+	if len(oldObjects) != 1 || len(newObjects) != 1 || isDir(args[0]) || isDir(args[1]) {
+		return c.compareSnapshots(args, oldObjects, newObjects)
+	}
+
+	old, nu := oldObjects[0], newObjects[0]
+	opts, err := c.options(old, nu)
+	if err != nil {
+		return err
+	}
+	diff, err := opts.StatusCompare(old, nu)
+	if err != nil {
+		return err
+	}
+	result := CompareResult{Old: args[0], New: args[1], Equal: diff.Equal(), StatusDiff: diff}
+	if err := c.print(result, func(w io.Writer) { printDiff(w, diff) }); err != nil {
+		return err
+	}
+	if !diff.Equal() {
+		return errDifferent
+	}
+	return nil
+}
+
+func printDiff(w io.Writer, diff *StatusDiff) {
+	for _, f := range diff.Fields {
+		fmt.Fprintln(w, f)
+	}
+	for _, warning := range diff.Warnings {
+		fmt.Fprintf(w, "warning: %s\n", warning)
+	}
+}
+
+// CompareSnapshotsResult is the json and yaml output of the compare command for
+// directories and manifests of several objects.
+type CompareSnapshotsResult struct {
+	Old           string `json:"old"`
+	New           string `json:"new"`
+	Equal         bool   `json:"equal"`
+	*SnapshotDiff `json:",inline"`
+}
+
+func (c *cli) compareSnapshots(args []string, old, new []*unstructured.Unstructured) error {
+	diff, err := CompareSnapshots(old, new, c.options)
+	if err != nil {
+		return err
+	}
+	result := CompareSnapshotsResult{Old: args[0], New: args[1], Equal: diff.Equal(), SnapshotDiff: diff}
+	if err := c.print(result, func(w io.Writer) { printSnapshotDiff(w, diff) }); err != nil {
+		return err
+	}
+	if !diff.Equal() {
+		return errDifferent
+	}
+	return nil
+}
+
+func printSnapshotDiff(w io.Writer, diff *SnapshotDiff) {
+	for _, o := range diff.Objects {
+		fmt.Fprintf(w, "%s %s\n", strings.ToLower(string(o.Op)), o.Object)
+		if o.Diff != nil {
+			printIndented(w, o.Diff)
+		}
+	}
+}
+
+// printIndented is like printDiff but indents every line below the object it belongs to.
+func printIndented(w io.Writer, diff *StatusDiff) {
+	var buf strings.Builder
+	printDiff(&buf, diff)
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+}
+
+// HashResult is the json and yaml output of the hash command.
+type HashResult struct {
+	Object string `json:"object"`
+	Hash   string `json:"hash"`
+}
+
+func (c *cli) hash(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("hash takes at least 1 object")
+	}
+	results := make([]HashResult, 0, len(args))
+	for _, arg := range args {
+		obj, err := c.object(context.Background(), arg)
+		if err != nil {
+			return err
+		}
+		opts, err := c.options(nil, obj)
+		if err != nil {
+			return err
+		}
+		hash, err := StatusHashE(obj, opts)
+		if err != nil {
+			return err
+		}
+		results = append(results, HashResult{Object: arg, Hash: hash})
+	}
+	return c.print(results, func(w io.Writer) {
+		for _, result := range results {
+			fmt.Fprintf(w, "%s  %s\n", result.Hash, result.Object)
+		}
+	})
+}
+
+// ExplainResult is the json and yaml output of the explain command.
+type ExplainResult struct {
+	Object string `json:"object"`
+	Kind   string `json:"kind"`
+	// Registered is false if the object is compared with the default policy.
+	Registered      bool       `json:"registered"`
+	Policy          PolicySpec `json:"policy"`
+	Stale           bool       `json:"stale"`
+	StaleConditions []string   `json:"staleConditions,omitempty"`
+}
+
+func (c *cli) explain(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("explain takes 1 object, got %d", len(args))
+	}
+	obj, err := c.object(context.Background(), args[0])
+	if err != nil {
+		return err
+	}
+	r, err := c.policies()
+	if err != nil {
+		return err
+	}
+	gk := obj.GroupVersionKind().GroupKind()
+	opts, registered := r.Lookup(gk)
+	stale, err := IsStatusStale(obj)
+	if err != nil {
+		return err
+	}
+	staleConditions, err := StaleConditionTypes(obj)
+	if err != nil {
+		return err
+	}
+	sort.Strings(staleConditions)
+	result := ExplainResult{
+		Object:          args[0],
+		Kind:            gk.String(),
+		Registered:      registered,
+		Policy:          PolicySpecFor(opts),
+		Stale:           stale,
+		StaleConditions: staleConditions,
+	}
+	return c.print(result, func(w io.Writer) {
+		policy := "default"
+		if registered {
+			policy = gk.String()
+		}
+		fmt.Fprintf(w, "Object:           %s\n", result.Object)
+		fmt.Fprintf(w, "Kind:             %s\n", result.Kind)
+		fmt.Fprintf(w, "Policy:           %s\n", policy)
+		fmt.Fprintf(w, "Stale:            %v\n", result.Stale)
+		if len(staleConditions) > 0 {
+			fmt.Fprintf(w, "Stale Conditions: %s\n", strings.Join(staleConditions, ", "))
+		}
+		data, err := yaml.Marshal(result.Policy)
+		if err != nil || string(data) == "{}\n" {
+			return
+		}
+		fmt.Fprintf(w, "Rules:\n")
+		for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+	})
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clientsetscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+func newTestCLI(objects ...runtime.Object) (*cli, *bytes.Buffer, *bytes.Buffer) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	var stdout, stderr bytes.Buffer
+	return &cli{
+		stdout:           &stdout,
+		stderr:           &stderr,
+		client:           dynamicfake.NewSimpleDynamicClient(clientsetscheme.Scheme, objects...),
+		mapper:           mapper,
+		defaultNamespace: "demo",
+	}, &stdout, &stderr
+}
+
+func named(obj runtime.Object, name string) *unstructured.Unstructured {
+	u := obj.(*unstructured.Unstructured).DeepCopy()
+	u.SetName(name)
+	return u
+}
+
+func TestCLI_Compare(t *testing.T) {
+	objects := []runtime.Object{
+		named(toJSON(a1), "d1"),
+		named(toJSON(a1ConditionTimeUpdated), "d2"),
+		named(toJSON(a1ConditionStatusUpdated), "d3"),
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantOut  string
+	}{
+		{
+			name:     "Equal",
+			args:     []string{"compare", "deployments/d1", "deployments/d2"},
+			wantCode: exitEqual,
+			wantOut:  "",
+		},
+		{
+			name:     "Different",
+			args:     []string{"compare", "deployments/d1", "deployment.apps/d3"},
+			wantCode: exitDifferent,
+			wantOut:  "status.conditions[type=Progressing].status: True -> False\n",
+		},
+		{
+			name:     "Not Found",
+			args:     []string{"compare", "deployments/d1", "deployments/d4"},
+			wantCode: exitError,
+		},
+		{
+			name:     "Missing Name",
+			args:     []string{"compare", "deployments/d1", "deployments"},
+			wantCode: exitError,
+		},
+		{
+			name:     "Unknown Command",
+			args:     []string{"diff", "deployments/d1", "deployments/d2"},
+			wantCode: exitError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, stdout, stderr := newTestCLI(objects...)
+			if code := c.run(tt.args); code != tt.wantCode {
+				t.Errorf("run() = %v, want %v, stderr: %s", code, tt.wantCode, stderr)
+			}
+			if got := stdout.String(); got != tt.wantOut {
+				t.Errorf("run() output = %q, want %q", got, tt.wantOut)
+			}
+		})
+	}
+}
+
+func TestCLI_CompareJSON(t *testing.T) {
+	c, stdout, _ := newTestCLI(named(toJSON(a1), "d1"), named(toJSON(a1ReplicasUpdated), "d2"))
+	if code := c.run([]string{"compare", "-o", "json", "-n", "demo", "deployments/d1", "deployments/d2"}); code != exitDifferent {
+		t.Fatalf("run() = %v, want %v", code, exitDifferent)
+	}
+	var result CompareResult
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatalf("invalid json output %s: %v", stdout, err)
+	}
+	if result.Equal || result.StatusDiff == nil || len(result.Fields) == 0 {
+		t.Errorf("run() output = %s, want differing fields", stdout)
+	}
+}
+
+func TestCLI_Hash(t *testing.T) {
+	c, stdout, stderr := newTestCLI(named(toJSON(a1), "d1"), named(toJSON(a1ConditionTimeUpdated), "d2"))
+	if code := c.run([]string{"hash", "deployments/d1", "deployments/d2"}); code != exitEqual {
+		t.Fatalf("run() = %v, want %v, stderr: %s", code, exitEqual, stderr)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("run() output = %q, want 2 lines", stdout)
+	}
+	if strings.Fields(lines[0])[0] != strings.Fields(lines[1])[0] {
+		t.Errorf("run() hashes differ: %q", stdout)
+	}
+}
+
+func TestCLI_Explain(t *testing.T) {
+	c, stdout, stderr := newTestCLI(named(toJSON(a1), "d1"))
+	if code := c.run([]string{"explain", "-o", "yaml", "deployments/d1"}); code != exitEqual {
+		t.Fatalf("run() = %v, want %v, stderr: %s", code, exitEqual, stderr)
+	}
+	for _, want := range []string{"kind: Deployment.apps", "registered: true", "- lastUpdateTime"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("run() output = %s, want %q", stdout, want)
+		}
+	}
+}
+
+func TestCLI_CompareFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		filename := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(filename, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+	old := write("old.yaml", a1)
+	timeUpdated := write("time.yaml", a1ConditionTimeUpdated)
+	replicasUpdated := write("replicas.yaml", a1ReplicasUpdated)
+	reasonUpdated := write("reason.yaml", a1ConditionReasonUpdated)
+	list := write("list.yaml", "apiVersion: v1\nkind: List\nitems: []\n")
+	node := "apiVersion: v1\nkind: Node\nmetadata:\n  name: n1\nstatus:\n  addresses:\n  - type: Hostname\n    address: n1\n"
+	nodeOld := write("node-old.yaml", node)
+	nodeNew := write("node-new.yaml", node+"  - type: InternalIP\n    address: 10.0.0.1\n")
+
+	tests := []struct {
+		name     string
+		args     []string
+		stdin    string
+		wantCode int
+		wantOut  string
+		wantErr  string
+	}{
+		{
+			name:     "Equal",
+			args:     []string{"compare", old, timeUpdated},
+			wantCode: exitEqual,
+		},
+		{
+			name:     "Different",
+			args:     []string{"compare", old, replicasUpdated},
+			wantCode: exitDifferent,
+			wantOut:  "status.availableReplicas: 3 -> 2\nstatus.readyReplicas: 3 -> 2\n",
+		},
+		{
+			name:     "Ignored",
+			args:     []string{"compare", "--ignore-path", "status.readyReplicas", "--ignore-path", "status.availableReplicas", old, replicasUpdated},
+			wantCode: exitEqual,
+		},
+		{
+			name:     "Stdin",
+			args:     []string{"compare", old, "-"},
+			stdin:    a1ReplicasUpdated,
+			wantCode: exitDifferent,
+			wantOut:  "status.availableReplicas: 3 -> 2\nstatus.readyReplicas: 3 -> 2\n",
+		},
+		{
+			name:     "Ignored List Map Item",
+			args:     []string{"compare", "--ignore-path", "status.addresses[type=InternalIP,address=10.0.0.1]", nodeOld, nodeNew},
+			wantCode: exitEqual,
+		},
+		{
+			name:     "List Map Item",
+			args:     []string{"compare", nodeOld, nodeNew},
+			wantCode: exitDifferent,
+		},
+		{
+			name:     "Condition Fields",
+			args:     []string{"compare", "--condition-fields", "*", old, reasonUpdated},
+			wantCode: exitDifferent,
+		},
+		{
+			name:     "Condition Fields Time Ignored",
+			args:     []string{"compare", "--condition-fields", "*", old, timeUpdated},
+			wantCode: exitEqual,
+		},
+		{
+			name:     "Invalid Empty Equivalence",
+			args:     []string{"compare", "--empty-equivalence", "Some", old, timeUpdated},
+			wantCode: exitError,
+		},
+		{
+			name:     "List",
+			args:     []string{"compare", old, list},
+			wantCode: exitDifferent,
+			wantOut:  "removed apps/v1 Deployment demo/d1\n",
+		},
+		{
+			name:     "Missing File",
+			args:     []string{"compare", old, filepath.Join(dir, "missing.yaml")},
+			wantCode: exitError,
+			wantErr:  "no such file or directory",
+		},
+		{
+			name:     "Missing Nested File",
+			args:     []string{"compare", old, "backups/0518/d1"},
+			wantCode: exitError,
+			wantErr:  "no such file or directory",
+		},
+		{
+			name:     "Multiple Objects",
+			args:     []string{"hash", list},
+			wantCode: exitError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, stdout, stderr := newTestCLI()
+			c.stdin = strings.NewReader(tt.stdin)
+			if code := c.run(tt.args); code != tt.wantCode {
+				t.Errorf("run() = %v, want %v, stderr: %s", code, tt.wantCode, stderr)
+			}
+			if tt.wantOut != "" && stdout.String() != tt.wantOut {
+				t.Errorf("run() output = %q, want %q", stdout, tt.wantOut)
+			}
+			if !strings.Contains(stderr.String(), tt.wantErr) {
+				t.Errorf("run() error = %q, want %q", stderr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCLI_CompareDirectories(t *testing.T) {
+	write := func(dir, name, content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d2 := strings.Replace(a1, "name: d1", "name: d2", 1)
+	d3 := strings.Replace(a1, "name: d1", "name: d3", 1)
+
+	old := t.TempDir()
+	write(old, "d1.yaml", a1)
+	write(old, "d2.yaml", d2)
+	write(old, "README.md", "not a manifest")
+	nu := t.TempDir()
+	if err := os.Mkdir(filepath.Join(nu, "demo"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write(filepath.Join(nu, "demo"), "deployments.yaml", a1ReplicasUpdated+"\n---\n"+d3)
+
+	c, stdout, stderr := newTestCLI()
+	if code := c.run([]string{"compare", old, nu}); code != exitDifferent {
+		t.Fatalf("run() = %v, want %v, stderr: %s", code, exitDifferent, stderr)
+	}
+	want := `changed apps/v1 Deployment demo/d1
+  status.availableReplicas: 3 -> 2
+  status.readyReplicas: 3 -> 2
+removed apps/v1 Deployment demo/d2
+added apps/v1 Deployment demo/d3
+`
+	if got := stdout.String(); got != want {
+		t.Errorf("run() output = %q, want %q", got, want)
+	}
+
+	c, _, stderr = newTestCLI()
+	if code := c.run([]string{"compare", old, old}); code != exitEqual {
+		t.Errorf("run() = %v, want %v, stderr: %s", code, exitEqual, stderr)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// optionFlags override the policy of the compared objects from the command line.
+type optionFlags struct {
+	ignorePaths            []string
+	conditionCompareFields []string
+	conditionIgnoreFields  []string
+	listMapKeys            []string
+	listSets               []string
+	emptyEquivalence       string
+	semanticValues         bool
+}
+
+func (c *cli) addOptionFlags(fs *pflag.FlagSet) {
+	o := &c.overrides
+	fs.StringArrayVar(&o.ignorePaths, "ignore-path", nil, "Status field path that is never compared, eg, status.stats (repeatable)")
+	fs.StringSliceVar(&o.conditionCompareFields, "condition-fields", nil, `Condition fields that are compared, "*" for all. Defaults to type, status and observedGeneration`)
+	fs.StringSliceVar(&o.conditionIgnoreFields, "ignore-condition-field", nil, "Condition field that is never compared (repeatable)")
+	fs.StringArrayVar(&o.listMapKeys, "list-map-key", nil, "List compared ignoring order by the given keys, eg, status.members=name (repeatable)")
+	fs.StringArrayVar(&o.listSets, "list-set", nil, "List of scalars compared ignoring order and duplicates (repeatable)")
+	fs.StringVar(&o.emptyEquivalence, "empty-equivalence", "", "Missing, null and empty fields that are equal, one of Strict, NullMissing or All")
+	fs.BoolVar(&o.semanticValues, "semantic-values", false, "Compare values that parse as times, quantities or int-or-strings semantically")
+}
+
+// apply returns opts with the flags that were set added to it or replacing its settings.
+func (o optionFlags) apply(opts EqualOptions) (EqualOptions, error) {
+	// the policies of a Registry are shared, so they are copied before they are modified
+	opts.IgnorePaths = append(append([]string(nil), opts.IgnorePaths...), o.ignorePaths...)
+	opts.ConditionIgnoreFields = append(append([]string(nil), opts.ConditionIgnoreFields...), o.conditionIgnoreFields...)
+	opts.ListSets = append(append([]string(nil), opts.ListSets...), o.listSets...)
+	if len(o.conditionCompareFields) > 0 {
+		opts.ConditionCompareFields = o.conditionCompareFields
+	}
+	if len(o.listMapKeys) > 0 {
+		keys := make(map[string][]string, len(opts.ListMapKeys)+len(o.listMapKeys))
+		for path, k := range opts.ListMapKeys {
+			keys[path] = k
+		}
+		for _, arg := range o.listMapKeys {
+			parts := strings.SplitN(arg, "=", 2)
+			if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				return opts, fmt.Errorf("invalid list map key %q, expected PATH=KEY[,KEY...]", arg)
+			}
+			keys[parts[0]] = strings.Split(parts[1], ",")
+		}
+		opts.ListMapKeys = keys
+	}
+	if o.emptyEquivalence != "" {
+		e, err := parseEmptyEquivalence(o.emptyEquivalence)
+		if err != nil {
+			return opts, err
+		}
+		opts.EmptyEquivalence = e
+	}
+	if o.semanticValues {
+		opts.SemanticValues = true
+	}
+	return opts, nil
+}
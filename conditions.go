@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// decodeConditions converts a condition list into its json representation,
+// so that typed and unstructured conditions expose the same field names and values.
+func decodeConditions(in interface{}) ([]map[string]interface{}, error) {
+	if in == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]map[string]interface{}, 0)
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// conditionKey returns a canonical representation of the compared fields of a condition.
+func (opts EqualOptions) conditionKey(c map[string]interface{}) string {
+	fields := make(map[string]interface{}, len(c))
+	for k, v := range c {
+		if opts.compareConditionField(k) {
+			fields[k] = v
+		}
+	}
+	// json.Marshal sorts map keys
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Sprintf("%v", fields)
+	}
+	return string(data)
+}
+
+// conditionsEqual reports whether old and nu hold the same conditions, ignoring order.
+func (opts EqualOptions) conditionsEqual(old, nu []map[string]interface{}) bool {
+	// optimization
+	if len(old) != len(nu) {
+		return false
+	}
+	count := make(map[string]int, len(old))
+	for _, c := range old {
+		count[opts.conditionKey(c)]++
+	}
+	for _, c := range nu {
+		key := opts.conditionKey(c)
+		if count[key] == 0 {
+			return false
+		}
+		count[key]--
+	}
+	return true
+}
+
+func conditionType(c map[string]interface{}) string {
+	switch t := c["type"].(type) {
+	case string:
+		return t
+	case nil:
+		return ""
+	}
+	// typed conditions use named string types, eg, apps.DeploymentConditionType
+	if v := reflect.ValueOf(c["type"]); v.Kind() == reflect.String {
+		return v.String()
+	}
+	return ""
+}
+
+// conditionsByType groups conditions by their type. Each type is expected to appear once.
+func conditionsByType(conditions []map[string]interface{}) map[string][]map[string]interface{} {
+	out := make(map[string][]map[string]interface{}, len(conditions))
+	for _, c := range conditions {
+		t := conditionType(c)
+		out[t] = append(out[t], c)
+	}
+	return out
+}
+
+// conditionValue returns the single condition of a type or all of them if the type is duplicated.
+func conditionValue(conditions []map[string]interface{}) interface{} {
+	if len(conditions) == 1 {
+		return conditions[0]
+	}
+	return conditions
+}
+
+// isConditionList reports whether old and nu are lists of conditions, ie, every item
+// is an object with a type and a status. Empty lists match if the other list does.
+func isConditionList(old, nu interface{}) bool {
+	oldList, ok := old.([]interface{})
+	if !ok && old != nil {
+		return false
+	}
+	nuList, ok := nu.([]interface{})
+	if !ok && nu != nil {
+		return false
+	}
+	if len(oldList) == 0 && len(nuList) == 0 {
+		return false
+	}
+	for _, list := range [][]interface{}{oldList, nuList} {
+		for _, item := range list {
+			c, ok := item.(map[string]interface{})
+			if !ok {
+				return false
+			}
+			if _, ok := c["type"]; !ok {
+				return false
+			}
+			if _, ok := c["status"]; !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,421 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type DiffOp string
+
+const (
+	DiffAdded   DiffOp = "Added"
+	DiffRemoved DiffOp = "Removed"
+	DiffChanged DiffOp = "Changed"
+)
+
+// FieldDiff describes a single field that differs between two statuses.
+// Path is rooted at "status", eg, status.readyReplicas or
+// status.conditions[type=Progressing].status
+type FieldDiff struct {
+	Path string      `json:"path"`
+	Op   DiffOp      `json:"op"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+func (f FieldDiff) String() string {
+	switch f.Op {
+	case DiffAdded:
+		return fmt.Sprintf("%s: added %v", f.Path, f.New)
+	case DiffRemoved:
+		return fmt.Sprintf("%s: removed %v", f.Path, f.Old)
+	default:
+		return fmt.Sprintf("%s: %v -> %v", f.Path, f.Old, f.New)
+	}
+}
+
+type StatusDiff struct {
+	Fields []FieldDiff `json:"fields,omitempty"`
+	// Warnings reports problems found while comparing, eg, duplicated condition types.
+	// They don't affect equality.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+func (d *StatusDiff) Equal() bool {
+	return d == nil || len(d.Fields) == 0
+}
+
+func (d *StatusDiff) String() string {
+	if d.Equal() {
+		return ""
+	}
+	lines := make([]string, 0, len(d.Fields))
+	for _, f := range d.Fields {
+		lines = append(lines, f.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// StatusCompare returns every field that differs between the status of old and new
+// using the default EqualOptions.
+func StatusCompare(old, new interface{}) (*StatusDiff, error) {
+	return EqualOptions{}.StatusCompare(old, new)
+}
+
+// StatusCompare returns every field that differs between the status of old and new.
+func (opts EqualOptions) StatusCompare(old, new interface{}) (*StatusDiff, error) {
+	oldStatus, oldExists, err := extractStatusFromObject(old)
+	if err != nil {
+		return nil, err
+	}
+	newStatus, newExists, err := extractStatusFromObject(new)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := opts.newDiffer()
+	if err != nil {
+		return nil, err
+	}
+	// a missing status equals a status whose fields are all empty
+	if opts.EmptyEquivalence == EmptyAll {
+		if !oldExists && statusKind(newStatus) == "object" {
+			oldStatus, oldExists = map[string]interface{}{}, true
+		}
+		if !newExists && statusKind(oldStatus) == "object" {
+			newStatus, newExists = map[string]interface{}{}, true
+		}
+	}
+	switch {
+	case !oldExists && !newExists, d.ignore.Match("status"):
+	case !oldExists && opts.equivalentToMissing(newStatus), !newExists && opts.equivalentToMissing(oldStatus):
+	case !oldExists:
+		d.add(FieldDiff{Path: "status", Op: DiffAdded, New: newStatus})
+	case !newExists:
+		d.add(FieldDiff{Path: "status", Op: DiffRemoved, Old: oldStatus})
+	case sameStructType(oldStatus, newStatus):
+		d.compareTyped("status", reflect.ValueOf(oldStatus), reflect.ValueOf(newStatus))
+	default:
+		oldKind, newKind := statusKind(oldStatus), statusKind(newStatus)
+		if oldKind != newKind {
+			return nil, fmt.Errorf("%w: old status kind %s does not match new status kind %s", ErrKindMismatch, oldKind, newKind)
+		}
+		if oldKind != "object" {
+			if err := d.compareValues("status", oldStatus, newStatus); err != nil {
+				return nil, err
+			}
+			break
+		}
+		// typed and unstructured status, or different Go types, compared like typed values
+		t := statusGoType(new, newStatus, opts.scheme())
+		if t == nil {
+			t = statusGoType(old, oldStatus, opts.scheme())
+		}
+		d.useStatusType(t)
+		oldMap, err := statusToMap(oldStatus)
+		if err != nil {
+			return nil, err
+		}
+		newMap, err := statusToMap(newStatus)
+		if err != nil {
+			return nil, err
+		}
+		if err := d.compareMaps("status", oldMap, newMap); err != nil {
+			return nil, err
+		}
+	}
+	return &StatusDiff{Fields: d.fields, Warnings: d.warnings}, nil
+}
+
+// newDiffer compiles the paths of opts.
+func (opts EqualOptions) newDiffer() (*differ, error) {
+	ignore, err := newPathMatcher(opts.IgnorePaths)
+	if err != nil {
+		return nil, err
+	}
+
+	conditionPaths := opts.ConditionPaths
+	if len(conditionPaths) == 0 {
+		conditionPaths = DefaultConditionPaths
+	}
+	conditions, err := newPathMatcher(conditionPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	valueTypes, err := newValueTypeMatchers(opts.ValueTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	listMaps, err := newListMapMatchers(opts.ListMapKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	listSets, err := newPathMatcher(opts.ListSets)
+	if err != nil {
+		return nil, err
+	}
+
+	return &differ{
+		opts:       opts,
+		ignore:     ignore,
+		conditions: conditions,
+		valueTypes: valueTypes,
+		listMaps:   listMaps,
+		listSets:   listSets,
+	}, nil
+}
+
+// statusKind returns "object" for maps and structs, the kind of the value otherwise.
+func statusKind(status interface{}) string {
+	v := reflect.ValueOf(status)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Map, reflect.Struct:
+		return "object"
+	}
+	return v.Kind().String()
+}
+
+// statusToMap converts status into its unstructured representation, so that typed
+// and unstructured statuses can be compared with each other.
+func statusToMap(status interface{}) (map[string]interface{}, error) {
+	if m, ok := status.(map[string]interface{}); ok {
+		return m, nil
+	}
+	v := reflect.ValueOf(status)
+	if v.Kind() != reflect.Ptr {
+		if v.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("%w: status of kind %s is not an object", ErrStatusDecode, v.Kind())
+		}
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		v = ptr
+	} else if v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: status of kind %s is not an object", ErrStatusDecode, v.Elem().Kind())
+	}
+	m, err := runtime.DefaultUnstructuredConverter.ToUnstructured(v.Interface())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStatusDecode, err)
+	}
+	return m, nil
+}
+
+type differ struct {
+	opts       EqualOptions
+	ignore     pathMatcher
+	conditions pathMatcher
+	valueTypes []valueTypeMatcher
+	listMaps   []listMapMatcher
+	listSets   pathMatcher
+	fields     []FieldDiff
+	warnings   []string
+}
+
+// useStatusType compares the quantity and int-or-string fields of the status Go type t
+// like compareTyped, whatever the ValueTypes of the options.
+func (d *differ) useStatusType(t reflect.Type) {
+	if t == nil {
+		return
+	}
+	d.valueTypes = append(append([]valueTypeMatcher{}, valueTypesOf(t)...), d.valueTypes...)
+}
+
+func (d *differ) add(f FieldDiff) {
+	d.fields = append(d.fields, f)
+}
+
+func (d *differ) compareMaps(path string, old, nu map[string]interface{}) error {
+	for _, key := range unionKeys(old, nu) {
+		p := path + "." + key
+		if d.ignore.Match(p) {
+			continue
+		}
+		oldVal, oldOk := old[key]
+		newVal, newOk := nu[key]
+		switch {
+		case !oldOk && d.missing(p, newVal), !newOk && d.missing(p, oldVal):
+		case !oldOk:
+			d.add(FieldDiff{Path: p, Op: DiffAdded, New: newVal})
+		case !newOk:
+			d.add(FieldDiff{Path: p, Op: DiffRemoved, Old: oldVal})
+		default:
+			if err := d.compareValues(p, oldVal, newVal); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// missing reports whether v is treated like a missing field at path. With EmptyAll,
+// maps whose fields are all ignored or missing are missing too.
+func (d *differ) missing(path string, v interface{}) bool {
+	if d.opts.equivalentToMissing(v) {
+		return true
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok || d.opts.EmptyEquivalence != EmptyAll {
+		return false
+	}
+	for k, item := range m {
+		p := path + "." + k
+		if !d.ignore.Match(p) && !d.missing(p, item) {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *differ) compareValues(path string, old, nu interface{}) error {
+	if d.missing(path, old) && d.missing(path, nu) {
+		return nil
+	}
+	if d.conditions.MatchExact(path) || (d.opts.DetectConditions && isConditionList(old, nu)) {
+		return d.compareConditions(path, old, nu)
+	}
+
+	oldMap, oldOk := old.(map[string]interface{})
+	nuMap, nuOk := nu.(map[string]interface{})
+	if oldOk && nuOk {
+		return d.compareMaps(path, oldMap, nuMap)
+	}
+
+	oldList, oldOk := old.([]interface{})
+	nuList, nuOk := nu.([]interface{})
+	if keys := d.listMapKeys(path); keys != nil && (oldOk || old == nil) && (nuOk || nu == nil) {
+		if ok, err := d.compareListMap(path, keys, oldList, nuList); ok || err != nil {
+			return err
+		}
+	}
+	if d.listSets.MatchExact(path) && (oldOk || old == nil) && (nuOk || nu == nil) {
+		d.compareListSet(path, oldList, nuList)
+		return nil
+	}
+	if oldOk && nuOk && len(oldList) == len(nuList) {
+		for i := range oldList {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			if d.ignore.Match(p) {
+				continue
+			}
+			if err := d.compareValues(p, oldList[i], nuList[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if !d.valuesEqual(path, old, nu) {
+		d.add(FieldDiff{Path: path, Op: DiffChanged, Old: old, New: nu})
+	}
+	return nil
+}
+
+func (d *differ) compareConditions(path string, oldVal, newVal interface{}) error {
+	// null and empty condition lists are only equal if EmptyEquivalence says so,
+	// which compareValues checked already
+	if isNull(oldVal) != isNull(newVal) {
+		d.add(FieldDiff{Path: path, Op: DiffChanged, Old: oldVal, New: newVal})
+		return nil
+	}
+	oldCond, err := decodeConditions(oldVal)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrStatusDecode, path, err)
+	}
+	nuCond, err := decodeConditions(newVal)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrStatusDecode, path, err)
+	}
+	d.compareConditionLists(path, oldCond, nuCond)
+	return nil
+}
+
+func (d *differ) compareConditionLists(path string, oldCond, nuCond []map[string]interface{}) {
+	oldCond = d.filterConditions(path, oldCond)
+	nuCond = d.filterConditions(path, nuCond)
+
+	oldByType := conditionsByType(oldCond)
+	nuByType := conditionsByType(nuCond)
+	d.warnDuplicateConditions(path, "old", oldByType)
+	d.warnDuplicateConditions(path, "new", nuByType)
+
+	for _, t := range unionKeys(oldByType, nuByType) {
+		p := fmt.Sprintf("%s[type=%s]", path, t)
+		oc, oldOk := oldByType[t]
+		nc, newOk := nuByType[t]
+		switch {
+		case !oldOk:
+			d.add(FieldDiff{Path: p, Op: DiffAdded, New: conditionValue(nc)})
+		case !newOk:
+			d.add(FieldDiff{Path: p, Op: DiffRemoved, Old: conditionValue(oc)})
+		case len(oc) == 1 && len(nc) == 1:
+			for _, field := range unionKeys(oc[0], nc[0]) {
+				if !d.opts.compareConditionField(field) {
+					continue
+				}
+				if ov, nv := oc[0][field], nc[0][field]; !d.valuesEqual(p+"."+field, ov, nv) {
+					d.add(FieldDiff{Path: p + "." + field, Op: DiffChanged, Old: ov, New: nv})
+				}
+			}
+		default:
+			// duplicated type, compare all its entries ignoring order
+			if !d.opts.conditionsEqual(oc, nc) {
+				d.add(FieldDiff{Path: p, Op: DiffChanged, Old: oc, New: nc})
+			}
+		}
+	}
+}
+
+func (d *differ) warnDuplicateConditions(path, side string, byType map[string][]map[string]interface{}) {
+	for _, t := range unionKeys(byType) {
+		if n := len(byType[t]); n > 1 {
+			d.warnings = append(d.warnings, fmt.Sprintf("%s: %s status has %d conditions of type %s", path, side, n, t))
+		}
+	}
+}
+
+// filterConditions drops the conditions and condition fields matched by the ignored paths.
+func (d *differ) filterConditions(path string, conditions []map[string]interface{}) []map[string]interface{} {
+	if len(d.ignore) == 0 {
+		return conditions
+	}
+	out := make([]map[string]interface{}, 0, len(conditions))
+	for _, c := range conditions {
+		p := fmt.Sprintf("%s[type=%s]", path, conditionType(c))
+		if d.ignore.Match(p) {
+			continue
+		}
+		fields := make(map[string]interface{}, len(c))
+		for k, v := range c {
+			if !d.ignore.Match(p + "." + k) {
+				fields[k] = v
+			}
+		}
+		out = append(out, fields)
+	}
+	return out
+}
+
+// unionKeys returns the sorted union of the keys of the given maps.
+func unionKeys(maps ...interface{}) []string {
+	seen := map[string]bool{}
+	for _, m := range maps {
+		for _, k := range reflect.ValueOf(m).MapKeys() {
+			seen[k.String()] = true
+		}
+	}
+	keys := make([]string, 0, len(seen))
+	for k := range seen {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestStatusCompare(t *testing.T) {
+	type args struct {
+		old interface{}
+		new interface{}
+	}
+	tests := []struct {
+		name  string
+		args  args
+		paths []string
+	}{
+		{
+			name: "Map Same",
+			args: args{
+				old: toJSON(a1),
+				new: toJSON(a1),
+			},
+			paths: nil,
+		},
+		{
+			name: "Map Condition Time Modified",
+			args: args{
+				old: toJSON(a1),
+				new: toJSON(a1ConditionTimeUpdated),
+			},
+			paths: nil,
+		},
+		{
+			name: "Map Condition Status Modified",
+			args: args{
+				old: toJSON(a1),
+				new: toJSON(a1ConditionStatusUpdated),
+			},
+			paths: []string{"status.conditions[type=Progressing].status"},
+		},
+		{
+			name: "Map Missing Conditions",
+			args: args{
+				old: toJSON(a1),
+				new: toJSON(a1MissingCondition),
+			},
+			paths: []string{"status.conditions"},
+		},
+		{
+			name: "Struct Condition Status Modified",
+			args: args{
+				old: d1,
+				new: d1ConditionStatusUpdated,
+			},
+			paths: []string{"status.conditions[type=Progressing].status"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := StatusCompare(tt.args.old, tt.args.new)
+			if err != nil {
+				t.Fatalf("StatusCompare() error = %v", err)
+			}
+			var paths []string
+			for _, f := range got.Fields {
+				paths = append(paths, f.Path)
+			}
+			if !reflect.DeepEqual(paths, tt.paths) {
+				t.Errorf("StatusCompare() paths = %v, want %v", paths, tt.paths)
+			}
+		})
+	}
+}
+
+func TestStatusCompare_DuplicateConditions(t *testing.T) {
+	status := func(types ...string) *unstructured.Unstructured {
+		conditions := make([]interface{}, 0, len(types))
+		for _, t := range types {
+			conditions = append(conditions, map[string]interface{}{"type": t, "status": "True"})
+		}
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"status": map[string]interface{}{"conditions": conditions},
+		}}
+	}
+
+	got, err := StatusCompare(status("A", "A", "B"), status("A", "B", "B"))
+	if err != nil {
+		t.Fatalf("StatusCompare() error = %v", err)
+	}
+	var paths []string
+	for _, f := range got.Fields {
+		paths = append(paths, f.Path)
+	}
+	want := []string{"status.conditions[type=A]", "status.conditions[type=B]"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("StatusCompare() paths = %v, want %v", paths, want)
+	}
+	if len(got.Warnings) != 2 {
+		t.Errorf("StatusCompare() warnings = %v, want 2 warnings", got.Warnings)
+	}
+
+	got, err = StatusCompare(status("A", "B", "A"), status("A", "A", "B"))
+	if err != nil {
+		t.Fatalf("StatusCompare() error = %v", err)
+	}
+	if !got.Equal() {
+		t.Errorf("StatusCompare() = %v, want equal", got)
+	}
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"errors"
+)
+
+var (
+	// ErrUnsupportedObject is returned for objects that are neither *unstructured.Unstructured nor metav1.Object.
+	ErrUnsupportedObject = errors.New("unsupported object")
+	// ErrStatusDecode is returned when a status or one of its fields can't be decoded.
+	ErrStatusDecode = errors.New("failed to decode status")
+	// ErrKindMismatch is returned when the old and new status have different kinds, eg, struct and map.
+	ErrKindMismatch = errors.New("status kind mismatch")
+	// ErrGroupKindMismatch is returned when the old and new object are of different kinds, eg, Deployment and Pod.
+	ErrGroupKindMismatch = errors.New("object kind mismatch")
+	// ErrDuplicateObject is returned when a snapshot contains the same object more than once.
+	ErrDuplicateObject = errors.New("duplicate object")
+)
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestStatusEqualE(t *testing.T) {
+	type args struct {
+		old interface{}
+		new interface{}
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    bool
+		wantErr error
+	}{
+		{
+			name: "Same",
+			args: args{
+				old: toJSON(a1),
+				new: toJSON(a1),
+			},
+			want: true,
+		},
+		{
+			name: "Unsupported Object",
+			args: args{
+				old: "d1",
+				new: toJSON(a1),
+			},
+			wantErr: ErrUnsupportedObject,
+		},
+		{
+			name: "Nil Unstructured",
+			args: args{
+				old: (*unstructured.Unstructured)(nil),
+				new: toJSON(a1),
+			},
+			wantErr: ErrUnsupportedObject,
+		},
+		{
+			name: "Kind Mismatch",
+			args: args{
+				old: &unstructured.Unstructured{Object: map[string]interface{}{"status": "Ready"}},
+				new: toJSON(a1),
+			},
+			wantErr: ErrKindMismatch,
+		},
+		{
+			name: "Invalid Conditions",
+			args: args{
+				old: &unstructured.Unstructured{Object: map[string]interface{}{"status": map[string]interface{}{"conditions": "Ready"}}},
+				new: &unstructured.Unstructured{Object: map[string]interface{}{"status": map[string]interface{}{"conditions": "NotReady"}}},
+			},
+			wantErr: ErrStatusDecode,
+		},
+		{
+			name: "Null Status",
+			args: args{
+				old: &unstructured.Unstructured{Object: map[string]interface{}{"status": nil}},
+				new: &unstructured.Unstructured{Object: map[string]interface{}{}},
+			},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := StatusEqualE(tt.args.old, tt.args.new)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("StatusEqualE() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("StatusEqualE() = %v, want %v", got, tt.want)
+			}
+			if StatusEqual(tt.args.old, tt.args.new) != tt.want {
+				t.Errorf("StatusEqual() = %v, want %v", !tt.want, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+)
+
+const (
+	DefaultFlappingWindow    = 5 * time.Minute
+	DefaultFlappingThreshold = 4
+)
+
+// StatusPath is the Flapping path of transitions of the whole status.
+const StatusPath = "status"
+
+// Flapping reports an object whose status, or one of its conditions, changed more
+// often than the threshold of a FlappingDetector within its window.
+type Flapping struct {
+	Object ObjectKey `json:"object"`
+	// Path is StatusPath for the whole status, or the path of a condition, eg, status.conditions[type=Ready]
+	Path string `json:"path"`
+	// Transitions is the number of changes within the window.
+	Transitions int `json:"transitions"`
+	// Since is the time of the first change within the window.
+	Since metav1.Time `json:"since"`
+}
+
+func (f Flapping) String() string {
+	return fmt.Sprintf("%s %s: %d transitions since %s", f.Object, f.Path, f.Transitions, f.Since.UTC().Format(time.RFC3339))
+}
+
+// FlappingDetector tracks the status transitions of objects over a sliding window,
+// eg, conditions that oscillate between True and False, and reports the objects and
+// conditions that changed more than a threshold.
+// It is safe for concurrent use.
+type FlappingDetector struct {
+	// Clock returns the time of the observed transitions. Defaults to clock.RealClock.
+	Clock clock.PassiveClock
+
+	window     time.Duration
+	threshold  int
+	optionsFor OptionsFunc
+
+	mu sync.Mutex
+	// transitions holds the times of the changes of each object by path
+	transitions map[ObjectKey]map[string][]time.Time
+}
+
+// NewFlappingDetector returns a detector that reports objects and conditions that changed
+// more than threshold times within window. The status of the observed objects is compared
+// with the policy returned by optionsFor, or with the default EqualOptions if optionsFor is nil.
+// Zero window and threshold default to DefaultFlappingWindow and DefaultFlappingThreshold.
+func NewFlappingDetector(window time.Duration, threshold int, optionsFor OptionsFunc) *FlappingDetector {
+	if window <= 0 {
+		window = DefaultFlappingWindow
+	}
+	if threshold <= 0 {
+		threshold = DefaultFlappingThreshold
+	}
+	if optionsFor == nil {
+		optionsFor = func(_, _ interface{}) (EqualOptions, error) {
+			return EqualOptions{}, nil
+		}
+	}
+	return &FlappingDetector{
+		window:      window,
+		threshold:   threshold,
+		optionsFor:  optionsFor,
+		transitions: map[ObjectKey]map[string][]time.Time{},
+	}
+}
+
+// Observe records an update of an object, eg, from an informer's OnUpdate, and returns the
+// status and conditions of the object that are flapping after it.
+// An update counts as a transition of the status if the statuses are not equal, and as a
+// transition of every condition whose compared fields changed, ie, a condition that only
+// changed its lastTransitionTime or message is not flapping with the default EqualOptions.
+func (f *FlappingDetector) Observe(old, new interface{}) ([]Flapping, error) {
+	key, err := objectKeyOf(new)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := f.optionsFor(old, new)
+	if err != nil {
+		return nil, err
+	}
+	diff, err := opts.StatusCompare(old, new)
+	if err != nil {
+		return nil, err
+	}
+	if diff.Equal() {
+		return nil, nil
+	}
+
+	paths := map[string]bool{StatusPath: true}
+	for _, field := range diff.Fields {
+		if p, ok := conditionPath(field.Path); ok {
+			paths[p] = true
+		}
+	}
+
+	now := f.now()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	transitions := f.prune(key, now)
+	if transitions == nil {
+		transitions = map[string][]time.Time{}
+		f.transitions[key] = transitions
+	}
+	var out []Flapping
+	for _, p := range unionKeys(paths) {
+		times := append(transitions[p], now)
+		transitions[p] = times
+		if len(times) > f.threshold {
+			out = append(out, Flapping{Object: key, Path: p, Transitions: len(times), Since: metav1.NewTime(times[0])})
+		}
+	}
+	return out, nil
+}
+
+// Forget drops the transitions of an object, eg, after it was deleted.
+func (f *FlappingDetector) Forget(obj interface{}) {
+	key, err := objectKeyOf(obj)
+	if err != nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.transitions, key)
+}
+
+// Flapping returns the status and conditions of every object that are flapping now,
+// sorted by object and path.
+func (f *FlappingDetector) Flapping() []Flapping {
+	now := f.now()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []Flapping
+	for key := range f.transitions {
+		transitions := f.prune(key, now)
+		for p, times := range transitions {
+			if len(times) > f.threshold {
+				out = append(out, Flapping{Object: key, Path: p, Transitions: len(times), Since: metav1.NewTime(times[0])})
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if a, b := out[i].Object.String(), out[j].Object.String(); a != b {
+			return a < b
+		}
+		return out[i].Path < out[j].Path
+	})
+	return out
+}
+
+// prune drops the transitions of an object that are outside the window ending at now
+// and returns the remaining ones, or nil if there are none.
+func (f *FlappingDetector) prune(key ObjectKey, now time.Time) map[string][]time.Time {
+	transitions := f.transitions[key]
+	for p, times := range transitions {
+		i := 0
+		for i < len(times) && now.Sub(times[i]) >= f.window {
+			i++
+		}
+		if i == len(times) {
+			delete(transitions, p)
+		} else {
+			transitions[p] = times[i:]
+		}
+	}
+	if len(transitions) == 0 {
+		delete(f.transitions, key)
+		return nil
+	}
+	return transitions
+}
+
+func (f *FlappingDetector) now() time.Time {
+	if f.Clock == nil {
+		return time.Now()
+	}
+	return f.Clock.Now()
+}
+
+// conditionPath returns the path of the condition a field diff belongs to, eg,
+// status.conditions[type=Ready] for status.conditions[type=Ready].status
+func conditionPath(path string) (string, bool) {
+	i := strings.LastIndex(path, "[type=")
+	if i < 0 {
+		return "", false
+	}
+	end := strings.Index(path[i:], "]")
+	if end < 0 {
+		return "", false
+	}
+	return path[:i+end+1], true
+}
+
+// objectKeyOf returns the key of a typed or unstructured object. The apiVersion and kind
+// of typed objects are empty unless their TypeMeta is set.
+func objectKeyOf(obj interface{}) (ObjectKey, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok && u != nil {
+		return ObjectKeyFor(u), nil
+	}
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return ObjectKey{}, fmt.Errorf("%w: %v", ErrUnsupportedObject, err)
+	}
+	key := ObjectKey{Namespace: m.GetNamespace(), Name: m.GetName()}
+	if o, ok := obj.(runtime.Object); ok {
+		key.APIVersion, key.Kind = o.GetObjectKind().GroupVersionKind().ToAPIVersionAndKind()
+	}
+	return key, nil
+}
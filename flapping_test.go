@@ -0,0 +1,146 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/clock"
+)
+
+func TestFlappingDetector(t *testing.T) {
+	ready := toJSON(a1)
+	notReady := toJSON(a1ConditionStatusUpdated)
+	timeUpdated := toJSON(a1ConditionTimeUpdated)
+	scaled := toJSON(a1ReplicasUpdated)
+
+	type update struct {
+		after time.Duration
+		old   interface{}
+		new   interface{}
+	}
+	tests := []struct {
+		name    string
+		updates []update
+		want    []string
+	}{
+		{
+			name: "Oscillating Condition",
+			updates: []update{
+				{after: 10 * time.Second, old: ready, new: notReady},
+				{after: 10 * time.Second, old: notReady, new: ready},
+				{after: 10 * time.Second, old: ready, new: notReady},
+			},
+			want: []string{"status", "status.conditions[type=Progressing]"},
+		},
+		{
+			name: "Outside Window",
+			updates: []update{
+				{after: 10 * time.Second, old: ready, new: notReady},
+				{after: 10 * time.Second, old: notReady, new: ready},
+				{after: time.Minute, old: ready, new: notReady},
+			},
+			want: nil,
+		},
+		{
+			name: "Equal Status",
+			updates: []update{
+				{after: 10 * time.Second, old: ready, new: timeUpdated},
+				{after: 10 * time.Second, old: timeUpdated, new: ready},
+				{after: 10 * time.Second, old: ready, new: timeUpdated},
+			},
+			want: nil,
+		},
+		{
+			name: "Status Only",
+			updates: []update{
+				{after: 10 * time.Second, old: ready, new: scaled},
+				{after: 10 * time.Second, old: scaled, new: ready},
+				{after: 10 * time.Second, old: ready, new: notReady},
+			},
+			want: []string{"status"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := clock.NewFakeClock(time.Date(2021, 5, 8, 19, 3, 45, 0, time.UTC))
+			d := NewFlappingDetector(time.Minute, 2, NewDefaultRegistry(nil).OptionsFor)
+			d.Clock = c
+
+			var got []string
+			for _, u := range tt.updates {
+				c.Step(u.after)
+				flapping, err := d.Observe(u.old, u.new)
+				if err != nil {
+					t.Fatalf("Observe() error = %v", err)
+				}
+				got = nil
+				for _, f := range flapping {
+					got = append(got, f.Path)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Observe() = %v, want %v", got, tt.want)
+			}
+
+			var current []string
+			for _, f := range d.Flapping() {
+				current = append(current, f.Path)
+			}
+			if !reflect.DeepEqual(current, tt.want) {
+				t.Errorf("Flapping() = %v, want %v", current, tt.want)
+			}
+
+			d.Forget(ready)
+			if f := d.Flapping(); len(f) != 0 {
+				t.Errorf("Flapping() after Forget() = %v, want none", f)
+			}
+		})
+	}
+}
+
+func TestFlappingDetector_Report(t *testing.T) {
+	start := time.Date(2021, 5, 8, 19, 3, 45, 0, time.UTC)
+	c := clock.NewFakeClock(start)
+	d := NewFlappingDetector(0, 0, nil)
+	d.Clock = c
+
+	old, nu := toJSON(a1), toJSON(a1ConditionStatusUpdated)
+	var flapping []Flapping
+	for i := 0; i <= DefaultFlappingThreshold; i++ {
+		var err error
+		if flapping, err = d.Observe(old, nu); err != nil {
+			t.Fatalf("Observe() error = %v", err)
+		}
+		old, nu = nu, old
+		c.Step(time.Second)
+	}
+	if len(flapping) != 2 {
+		t.Fatalf("Observe() = %v, want status and condition", flapping)
+	}
+	want := "apps/v1 Deployment demo/d1 status.conditions[type=Progressing]: 5 transitions since 2021-05-08T19:03:45Z"
+	if got := flapping[1].String(); got != want {
+		t.Errorf("Flapping.String() = %q, want %q", got, want)
+	}
+}
+
+func TestConditionPath(t *testing.T) {
+	tests := []struct {
+		path   string
+		want   string
+		wantOk bool
+	}{
+		{path: "status.readyReplicas"},
+		{path: "status.conditions[type=Ready].status", want: "status.conditions[type=Ready]", wantOk: true},
+		{path: "status.conditions[type=Ready]", want: "status.conditions[type=Ready]", wantOk: true},
+		{path: "status.members[name=m1].conditions[type=Synced].reason", want: "status.members[name=m1].conditions[type=Synced]", wantOk: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, ok := conditionPath(tt.path)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("conditionPath() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
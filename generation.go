@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IsStatusStale reports whether the status of obj was computed for an older
+// generation of its spec, ie, status.observedGeneration < metadata.generation.
+// Objects without status.observedGeneration are never reported as stale.
+func IsStatusStale(obj interface{}) (bool, error) {
+	meta, status, err := objectStatusMap(obj)
+	if err != nil || status == nil {
+		return false, err
+	}
+	observed, ok := toInt64(status["observedGeneration"])
+	if !ok {
+		return false, nil
+	}
+	return observed < meta.GetGeneration(), nil
+}
+
+// StaleConditionTypes returns the types of conditions whose observedGeneration is
+// older than metadata.generation. Conditions without observedGeneration are skipped.
+func StaleConditionTypes(obj interface{}) ([]string, error) {
+	meta, status, err := objectStatusMap(obj)
+	if err != nil || status == nil {
+		return nil, err
+	}
+	conditions, err := decodeConditions(status["conditions"])
+	if err != nil {
+		return nil, fmt.Errorf("%w: status.conditions: %v", ErrStatusDecode, err)
+	}
+	var types []string
+	for _, c := range conditions {
+		if observed, ok := toInt64(c["observedGeneration"]); ok && observed < meta.GetGeneration() {
+			types = append(types, conditionType(c))
+		}
+	}
+	return types, nil
+}
+
+func objectStatusMap(obj interface{}) (metav1.Object, map[string]interface{}, error) {
+	meta, ok := obj.(metav1.Object)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %T", ErrUnsupportedObject, obj)
+	}
+	status, exists, err := extractStatusFromObject(obj)
+	if err != nil || !exists {
+		return meta, nil, err
+	}
+	m, err := statusToMap(status)
+	if err != nil {
+		return meta, nil, err
+	}
+	return meta, m, nil
+}
+
+func toInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return n, true
+	case int32:
+		return int64(n), true
+	case int:
+		return int64(n), true
+	case float64:
+		return int64(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestIsStatusStale(t *testing.T) {
+	withGeneration := func(o *unstructured.Unstructured, generation int64) *unstructured.Unstructured {
+		out := o.DeepCopy()
+		out.SetGeneration(generation)
+		return out
+	}
+	stale := d1.DeepCopy()
+	stale.Generation = 3
+
+	tests := []struct {
+		name string
+		obj  interface{}
+		want bool
+	}{
+		{
+			name: "Map Observed",
+			obj:  withGeneration(toJSON(a1).(*unstructured.Unstructured), 2),
+			want: false,
+		},
+		{
+			name: "Map Stale",
+			obj:  withGeneration(toJSON(a1).(*unstructured.Unstructured), 3),
+			want: true,
+		},
+		{
+			name: "Struct Observed",
+			obj:  d1,
+			want: false,
+		},
+		{
+			name: "Struct Stale",
+			obj:  stale,
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := IsStatusStale(tt.obj)
+			if err != nil {
+				t.Fatalf("IsStatusStale() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IsStatusStale() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStaleConditionTypes(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"generation": int64(3)},
+		"status": map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": "True", "observedGeneration": int64(2)},
+				map[string]interface{}{"type": "Synced", "status": "True", "observedGeneration": int64(3)},
+				map[string]interface{}{"type": "Healthy", "status": "True"},
+			},
+		},
+	}}
+	got, err := StaleConditionTypes(obj)
+	if err != nil {
+		t.Fatalf("StaleConditionTypes() error = %v", err)
+	}
+	if want := []string{"Ready"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("StaleConditionTypes() = %v, want %v", got, want)
+	}
+}
@@ -4,10 +4,14 @@ go 1.16
 
 require (
 	github.com/fatih/structs v1.1.0
+	github.com/spf13/pflag v1.0.5
 	gomodules.xyz/pointer v0.0.0-20201105071923-daf60fa55209
-	k8s.io/api v0.21.0
-	k8s.io/apimachinery v0.21.0
-	k8s.io/client-go v0.21.0
+	k8s.io/api v0.21.1
+	k8s.io/apimachinery v0.21.1
+	k8s.io/client-go v0.21.1
 	k8s.io/klog/v2 v2.8.0
-	kmodules.xyz/client-go v0.0.0-20210505231546-fa4fb8e1d04e
+	k8s.io/kube-openapi v0.0.0-20210305001622-591a79e4bda7
+	sigs.k8s.io/controller-runtime v0.9.0
+	sigs.k8s.io/structured-merge-diff/v4 v4.1.0
+	sigs.k8s.io/yaml v1.2.0
 )
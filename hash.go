@@ -0,0 +1,398 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// StatusHash returns a hash of the status of obj that is equal for two objects if
+// StatusEqual reports their status as equal with the same opts, eg, it can be stored
+// in an annotation to skip reconciling an unchanged status.
+// Ignored fields, condition fields that are not compared, list order of list maps and
+// list sets, and values that are equal semantically don't change the hash.
+// With SemanticValues, numbers and the numeric strings they equal only hash the same if
+// they are formatted alike, eg, 1.5 and "1.5" but not 1.5 and "1.50", as numeric strings
+// are not equal to each other.
+// It returns "" if the status can't be hashed; callers should treat "" as matching nothing.
+func StatusHash(obj interface{}, opts EqualOptions) string {
+	hash, err := StatusHashE(obj, opts)
+	if err != nil {
+		klog.Warningln(err)
+		return ""
+	}
+	return hash
+}
+
+// StatusHashE is like StatusHash but returns an error if the status can't be hashed.
+func StatusHashE(obj interface{}, opts EqualOptions) (string, error) {
+	status, exists, err := extractStatusFromObject(obj)
+	if err != nil {
+		return "", err
+	}
+	d, err := opts.newDiffer()
+	if err != nil {
+		return "", err
+	}
+
+	var canonical interface{}
+	switch {
+	case !exists, d.ignore.Match("status"), opts.equivalentToMissing(status):
+		canonical = missingValue{}
+	case statusKind(status) != "object":
+		canonical = d.canonicalLeaf("status", status)
+	default:
+		m, err := statusToMap(status)
+		if err != nil {
+			return "", err
+		}
+		d.useStatusType(statusGoType(obj, status, opts.scheme()))
+		if canonical, err = d.canonicalValue("status", m); err != nil {
+			return "", err
+		}
+		// a missing status equals a status whose fields are all empty
+		if c, ok := canonical.(map[string]interface{}); ok && len(c) == 0 && opts.EmptyEquivalence == EmptyAll {
+			canonical = missingValue{}
+		}
+	}
+
+	h := sha256.New()
+	if err := writeCanonical(h, canonical); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// The canonical form of a status is a tree of maps, lists and leaves in which values
+// that compare equal are identical. Lists compared ignoring order get their own types,
+// so that they don't hash like maps or lists.
+type (
+	missingValue    struct{}
+	listMapValue    map[string]interface{}
+	listSetValue    []string
+	conditionsValue map[string]interface{}
+	leafValue       struct{ v interface{} }
+	semanticValue   struct {
+		typ ValueType
+		v   string
+	}
+)
+
+func (d *differ) canonicalValue(path string, v interface{}) (interface{}, error) {
+	if d.opts.equivalentToMissing(v) {
+		return nil, nil
+	}
+	if d.conditions.MatchExact(path) || (d.opts.DetectConditions && isConditionList(v, v)) {
+		if isNull(v) {
+			// a null condition list is different from an empty one, like in compareConditions
+			return nil, nil
+		}
+		return d.canonicalConditions(path, v)
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			p := path + "." + k
+			if d.ignore.Match(p) || d.opts.equivalentToMissing(item) {
+				continue
+			}
+			c, err := d.canonicalValue(p, item)
+			if err != nil {
+				return nil, err
+			}
+			// eg, maps whose fields are all ignored
+			if d.opts.EmptyEquivalence == EmptyAll && isEmptyCanonical(c) {
+				continue
+			}
+			out[k] = c
+		}
+		return out, nil
+	case []interface{}:
+		if keys := d.listMapKeys(path); keys != nil {
+			if index, ok := indexListMap(len(val), func(i int) (string, bool) {
+				item, ok := val[i].(map[string]interface{})
+				if !ok {
+					return "", false
+				}
+				return formatListMapKey(keys, func(k string) (interface{}, bool) {
+					v, ok := item[k]
+					return v, ok
+				})
+			}); ok {
+				out := make(listMapValue, len(index))
+				for k, i := range index {
+					p := fmt.Sprintf("%s[%s]", path, k)
+					if d.ignore.Match(p) {
+						continue
+					}
+					c, err := d.canonicalValue(p, val[i])
+					if err != nil {
+						return nil, err
+					}
+					out[k] = c
+				}
+				return out, nil
+			}
+		}
+		if d.listSets.MatchExact(path) {
+			return canonicalListSet(path, val, d), nil
+		}
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			if d.ignore.Match(p) {
+				out[i] = missingValue{}
+				continue
+			}
+			c, err := d.canonicalValue(p, item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = c
+		}
+		return out, nil
+	case nil:
+		// missing lists are compared like empty lists
+		if d.listMapKeys(path) != nil {
+			return listMapValue{}, nil
+		}
+		if d.listSets.MatchExact(path) {
+			return listSetValue{}, nil
+		}
+	}
+	return d.canonicalLeaf(path, v), nil
+}
+
+func canonicalListSet(path string, list []interface{}, d *differ) listSetValue {
+	seen := make(map[string]bool, len(list))
+	out := make(listSetValue, 0, len(list))
+	for _, item := range list {
+		k := fmt.Sprintf("%v", item)
+		if seen[k] || d.ignore.Match(fmt.Sprintf("%s[=%s]", path, k)) {
+			continue
+		}
+		seen[k] = true
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// canonicalConditions returns the compared fields of each condition by type.
+// Duplicated types are represented by the sorted keys of their conditions, as they
+// are compared ignoring order by conditionsEqual.
+func (d *differ) canonicalConditions(path string, v interface{}) (interface{}, error) {
+	conditions, err := decodeConditions(v)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrStatusDecode, path, err)
+	}
+	out := conditionsValue{}
+	for t, cs := range conditionsByType(d.filterConditions(path, conditions)) {
+		p := fmt.Sprintf("%s[type=%s]", path, t)
+		if len(cs) > 1 {
+			keys := make([]string, 0, len(cs))
+			for _, c := range cs {
+				keys = append(keys, d.opts.conditionKey(c))
+			}
+			sort.Strings(keys)
+			out[t] = keys
+			continue
+		}
+		fields := map[string]interface{}{}
+		for field, fv := range cs[0] {
+			if fv == nil || !d.opts.compareConditionField(field) {
+				continue
+			}
+			fields[field] = d.canonicalLeaf(p+"."+field, fv)
+		}
+		out[t] = fields
+	}
+	return out, nil
+}
+
+func isEmptyCanonical(c interface{}) bool {
+	switch val := c.(type) {
+	case map[string]interface{}:
+		return len(val) == 0
+	case []interface{}:
+		return len(val) == 0
+	}
+	return false
+}
+
+// canonicalLeaf returns the value that valuesEqual compares for v.
+func (d *differ) canonicalLeaf(path string, v interface{}) interface{} {
+	for _, m := range d.valueTypes {
+		if m.paths.MatchExact(path) {
+			if c, ok := canonicalTypedValue(m.typ, v); ok {
+				return c
+			}
+			return leafValue{v}
+		}
+	}
+	if d.opts.SemanticValues {
+		if c, ok := canonicalTypedValue(TimeValue, v); ok {
+			return c
+		}
+		// like valuesEqual, plain numeric strings are not quantities, eg, "1.20" and "1.2"
+		// are different, but quantities with a unit suffix equal their numeric value
+		if s, ok := numericString(v); ok {
+			return semanticValue{typ: IntOrStringValue, v: s}
+		}
+		if c, ok := canonicalTypedValue(IntOrStringValue, v); ok {
+			return c
+		}
+	}
+	return leafValue{v}
+}
+
+// numericString returns the decimal form of quantities with a unit suffix and of
+// non-integral numbers, eg, "1Ki" is "1024" and "500m" is "0.5", so that they hash like
+// the int-or-string form of the numbers and numeric strings they equal.
+func numericString(v interface{}) (string, bool) {
+	if f, ok := v.(float64); ok && f != float64(int64(f)) {
+		return strconv.FormatFloat(f, 'f', -1, 64), true
+	}
+	if !hasUnitSuffix(v) {
+		return "", false
+	}
+	q, err := parseQuantity(v)
+	if err != nil {
+		return "", false
+	}
+	s := q.AsDec().String()
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s, true
+}
+
+func canonicalTypedValue(typ ValueType, v interface{}) (interface{}, bool) {
+	switch typ {
+	case QuantityValue:
+		q, err := parseQuantity(v)
+		if err != nil {
+			return nil, false
+		}
+		r, ok := new(big.Rat).SetString(q.AsDec().String())
+		if !ok {
+			return nil, false
+		}
+		return semanticValue{typ: QuantityValue, v: r.RatString()}, true
+	case TimeValue, MicroTimeValue:
+		t, ok := parseTime(v)
+		if !ok {
+			return nil, false
+		}
+		precision := time.Second
+		if typ == MicroTimeValue {
+			precision = time.Microsecond
+		}
+		return semanticValue{typ: TimeValue, v: t.Truncate(precision).UTC().Format(time.RFC3339Nano)}, true
+	case IntOrStringValue:
+		s, ok := intOrString(v)
+		if !ok {
+			return nil, false
+		}
+		return semanticValue{typ: IntOrStringValue, v: s}, true
+	}
+	return nil, false
+}
+
+// writeCanonical writes an unambiguous encoding of a canonical value to w.
+// Map keys are sorted and every kind of value has its own delimiters.
+func writeCanonical(w io.Writer, v interface{}) error {
+	writeMap := func(open, close string, m map[string]interface{}) error {
+		if _, err := io.WriteString(w, open); err != nil {
+			return err
+		}
+		for _, k := range unionKeys(m) {
+			if _, err := io.WriteString(w, strconv.Quote(k)+":"); err != nil {
+				return err
+			}
+			if err := writeCanonical(w, m[k]); err != nil {
+				return err
+			}
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		_, err := io.WriteString(w, close)
+		return err
+	}
+
+	switch val := v.(type) {
+	case nil:
+		_, err := io.WriteString(w, "null")
+		return err
+	case missingValue:
+		_, err := io.WriteString(w, "-")
+		return err
+	case map[string]interface{}:
+		return writeMap("{", "}", val)
+	case listMapValue:
+		return writeMap("<", ">", val)
+	case conditionsValue:
+		return writeMap("c<", ">", val)
+	case []interface{}:
+		if _, err := io.WriteString(w, "["); err != nil {
+			return err
+		}
+		for _, item := range val {
+			if err := writeCanonical(w, item); err != nil {
+				return err
+			}
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		_, err := io.WriteString(w, "]")
+		return err
+	case listSetValue:
+		items := make([]interface{}, len(val))
+		for i, item := range val {
+			items[i] = item
+		}
+		if _, err := io.WriteString(w, "("); err != nil {
+			return err
+		}
+		data, err := json.Marshal(items)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, ")")
+		return err
+	case []string:
+		data, err := json.Marshal(val)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	case semanticValue:
+		_, err := io.WriteString(w, string(val.typ)+"("+strconv.Quote(val.v)+")")
+		return err
+	case leafValue:
+		data, err := json.Marshal(val.v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	return fmt.Errorf("unexpected canonical value %T", v)
+}
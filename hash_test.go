@@ -0,0 +1,237 @@
+package main
+
+import (
+	"testing"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+type endpointStatus struct {
+	Port intstr.IntOrString `json:"port"`
+}
+
+type endpoint struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Status            endpointStatus `json:"status,omitempty"`
+}
+
+func (e *endpoint) DeepCopyObject() runtime.Object {
+	out := *e
+	e.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	return &out
+}
+
+var endpointGVK = schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Endpoint"}
+
+func TestStatusHash(t *testing.T) {
+	node := func(memory string) *core.Node {
+		return &core.Node{Status: core.NodeStatus{Capacity: core.ResourceList{core.ResourceMemory: resource.MustParse(memory)}}}
+	}
+	containers := EqualOptions{ListMapKeys: map[string][]string{"status.containerStatuses": {"name"}}}
+	// unstructured objects of a kind in the scheme are hashed like their typed form
+	withKind := func(gvk schema.GroupVersionKind, u *unstructured.Unstructured) *unstructured.Unstructured {
+		u.SetGroupVersionKind(gvk)
+		return u
+	}
+	nodeGVK := core.SchemeGroupVersion.WithKind("Node")
+	unstructuredNode := func(memory string) *unstructured.Unstructured {
+		return withKind(nodeGVK, toUnstructured(node(memory)))
+	}
+	endpoints := runtime.NewScheme()
+	endpoints.AddKnownTypeWithName(endpointGVK, &endpoint{})
+
+	tests := []struct {
+		name string
+		opts EqualOptions
+		old  interface{}
+		new  interface{}
+		want bool
+	}{
+		{name: "Same", old: toJSON(a1), new: toJSON(a1), want: true},
+		{name: "Condition Time Updated", old: toJSON(a1), new: toJSON(a1ConditionTimeUpdated), want: true},
+		{name: "Condition Status Updated", old: toJSON(a1), new: toJSON(a1ConditionStatusUpdated), want: false},
+		{name: "Condition Missing", old: toJSON(a1), new: toJSON(a1MissingCondition), want: false},
+		{name: "Replicas Updated", old: toJSON(a1), new: toJSON(a1ReplicasUpdated), want: false},
+		{
+			name: "Condition Reason Compared",
+			opts: EqualOptions{ConditionCompareFields: []string{"type", "status", "reason"}},
+			old:  toJSON(a1),
+			new:  toJSON(a1ConditionReasonUpdated),
+			want: false,
+		},
+		{name: "Typed Unstructured", old: d1, new: toJSON(a1), want: true},
+		{name: "Typed Reordered", opts: containers, old: pod("app", "sidecar"), new: pod("sidecar", "app"), want: true},
+		{name: "Typed Reordered By Index", old: pod("app", "sidecar"), new: pod("sidecar", "app"), want: false},
+		{
+			name: "Typed Int Or String",
+			old:  &endpoint{Status: endpointStatus{Port: intstr.FromInt(80)}},
+			new:  &endpoint{Status: endpointStatus{Port: intstr.FromString("80")}},
+			want: true,
+		},
+		{
+			name: "Typed Int Or String Updated",
+			old:  &endpoint{Status: endpointStatus{Port: intstr.FromInt(80)}},
+			new:  &endpoint{Status: endpointStatus{Port: intstr.FromString("http")}},
+			want: false,
+		},
+		{name: "Typed Quantity", old: node("1Ki"), new: node("1024"), want: true},
+		{name: "Typed Quantity Updated", old: node("1Ki"), new: node("1000"), want: false},
+		{name: "Typed Unstructured Quantity", old: node("1Ki"), new: unstructuredNode("1Ki"), want: true},
+		{name: "Typed Unstructured Quantity Semantic", old: node("1Ki"), new: unstructuredNode("1024"), want: true},
+		{name: "Typed Unstructured Quantity Updated", old: node("1Ki"), new: unstructuredNode("1000"), want: false},
+		{name: "Unstructured Quantity Of Typed Kind", old: unstructuredNode("1Ki"), new: unstructuredNode("1024"), want: true},
+		{
+			name: "Typed Unstructured Int Or String",
+			opts: EqualOptions{Scheme: endpoints},
+			old:  &endpoint{Status: endpointStatus{Port: intstr.FromInt(80)}},
+			new:  withKind(endpointGVK, status("port", "80")),
+			want: true,
+		},
+		{
+			name: "Typed Unstructured Int Or String Updated",
+			opts: EqualOptions{Scheme: endpoints},
+			old:  &endpoint{Status: endpointStatus{Port: intstr.FromInt(80)}},
+			new:  withKind(endpointGVK, status("port", "http")),
+			want: false,
+		},
+		{
+			name: "List Set Reordered",
+			opts: EqualOptions{ListSets: []string{"status.addresses"}},
+			old:  status("addresses", []interface{}{"10.0.0.1", "10.0.0.2", "10.0.0.1"}),
+			new:  status("addresses", []interface{}{"10.0.0.2", "10.0.0.1"}),
+			want: true,
+		},
+		{
+			name: "Ignored Path",
+			opts: EqualOptions{IgnorePaths: []string{"status.stats"}},
+			old:  status("phase", "Ready", "stats", map[string]interface{}{"reads": int64(1)}),
+			new:  status("phase", "Ready", "stats", map[string]interface{}{"reads": int64(2)}),
+			want: true,
+		},
+		{name: "Strict Null Missing", old: status("members", nil), new: status(), want: false},
+		{name: "Strict Null Empty Conditions", old: status("conditions", nil), new: status("conditions", []interface{}{}), want: false},
+		{
+			name: "All Null Empty Conditions",
+			opts: EqualOptions{EmptyEquivalence: EmptyAll},
+			old:  status("conditions", nil),
+			new:  status("conditions", []interface{}{}),
+			want: true,
+		},
+		{
+			name: "All Empty Missing",
+			opts: EqualOptions{EmptyEquivalence: EmptyAll},
+			old:  status("members", []interface{}{}),
+			new:  status(),
+			want: true,
+		},
+		{
+			name: "All Empty Status Missing",
+			opts: EqualOptions{EmptyEquivalence: EmptyAll},
+			old:  status("members", []interface{}{}),
+			new:  &unstructured.Unstructured{Object: map[string]interface{}{}},
+			want: true,
+		},
+		{
+			name: "All Nested Empty Map",
+			opts: EqualOptions{EmptyEquivalence: EmptyAll},
+			old:  status("replicas", int64(1), "x", map[string]interface{}{"y": []interface{}{}}),
+			new:  status("replicas", int64(1), "x", map[string]interface{}{}),
+			want: true,
+		},
+		{
+			name: "All Ignored Map Missing",
+			opts: EqualOptions{EmptyEquivalence: EmptyAll, IgnorePaths: []string{"status.x.stats"}},
+			old:  status("replicas", int64(1), "x", map[string]interface{}{"stats": int64(1)}),
+			new:  status("replicas", int64(1)),
+			want: true,
+		},
+		{
+			name: "Value Type Quantity",
+			opts: EqualOptions{ValueTypes: map[string]ValueType{"status.capacity.*": QuantityValue}},
+			old:  status("capacity", map[string]interface{}{"cpu": "1000m", "memory": "1Ki"}),
+			new:  status("capacity", map[string]interface{}{"cpu": int64(1), "memory": "1024"}),
+			want: true,
+		},
+		{
+			name: "Semantic Time",
+			opts: EqualOptions{SemanticValues: true},
+			old:  status("startTime", "2021-05-08T19:03:45Z"),
+			new:  status("startTime", "2021-05-08T21:03:45.300+02:00"),
+			want: true,
+		},
+		{
+			name: "Semantic Int Or String",
+			opts: EqualOptions{SemanticValues: true},
+			old:  status("port", int64(80)),
+			new:  status("port", "80"),
+			want: true,
+		},
+		{
+			name: "Semantic Version",
+			opts: EqualOptions{SemanticValues: true},
+			old:  status("version", "1.20"),
+			new:  status("version", "1.2"),
+			want: false,
+		},
+		{
+			name: "Semantic Numeric String",
+			opts: EqualOptions{SemanticValues: true},
+			old:  status("replicas", "5"),
+			new:  status("replicas", "5.0"),
+			want: false,
+		},
+		{
+			name: "Semantic Quantity",
+			opts: EqualOptions{SemanticValues: true},
+			old:  status("cpu", "500m", "memory", "1Ki"),
+			new:  status("cpu", 0.5, "memory", "1024"),
+			want: true,
+		},
+		{
+			name: "Semantic Number",
+			opts: EqualOptions{SemanticValues: true},
+			old:  status("ratio", 1.5),
+			new:  status("ratio", "1.5"),
+			want: true,
+		},
+		{name: "Not Semantic", old: status("port", int64(80)), new: status("port", "80"), want: false},
+		{
+			name: "Tagged String",
+			opts: EqualOptions{SemanticValues: true},
+			old:  status("port", int64(80)),
+			new:  status("port", `IntOrString("80")`),
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldHash, err := StatusHashE(tt.old, tt.opts)
+			if err != nil {
+				t.Fatalf("StatusHashE() error = %v", err)
+			}
+			newHash, err := StatusHashE(tt.new, tt.opts)
+			if err != nil {
+				t.Fatalf("StatusHashE() error = %v", err)
+			}
+			if got := oldHash == newHash; got != tt.want {
+				t.Errorf("StatusHashE() equal = %v, want %v", got, tt.want)
+			}
+			if equal := tt.opts.StatusEqual(tt.old, tt.new); equal != tt.want {
+				t.Errorf("StatusEqual() = %v, want %v", equal, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusHash_Unsupported(t *testing.T) {
+	if got := StatusHash("d1", EqualOptions{}); got != "" {
+		t.Errorf("StatusHash() = %q, want empty hash", got)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"reflect"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// FilterStatusOnlyUpdates wraps handler so that OnUpdate is not called for updates
+// that only changed status fields which are equal according to opts.
+// Resync updates, ie, updates with the same resourceVersion, are always passed
+// and so are updates whose objects can't be compared.
+func FilterStatusOnlyUpdates(handler cache.ResourceEventHandler, opts EqualOptions) cache.ResourceEventHandler {
+	return &statusFilteringHandler{handler: handler, opts: opts}
+}
+
+type statusFilteringHandler struct {
+	handler cache.ResourceEventHandler
+	opts    EqualOptions
+}
+
+var _ cache.ResourceEventHandler = &statusFilteringHandler{}
+
+func (h *statusFilteringHandler) OnAdd(obj interface{}) {
+	h.handler.OnAdd(obj)
+}
+
+func (h *statusFilteringHandler) OnUpdate(oldObj, newObj interface{}) {
+	if h.irrelevantUpdate(oldObj, newObj) {
+		return
+	}
+	h.handler.OnUpdate(oldObj, newObj)
+}
+
+func (h *statusFilteringHandler) OnDelete(obj interface{}) {
+	h.handler.OnDelete(obj)
+}
+
+func (h *statusFilteringHandler) irrelevantUpdate(oldObj, newObj interface{}) bool {
+	oldMeta, ok := oldObj.(metav1.Object)
+	if !ok {
+		return false
+	}
+	newMeta, ok := newObj.(metav1.Object)
+	if !ok {
+		return false
+	}
+	if oldMeta.GetResourceVersion() == newMeta.GetResourceVersion() {
+		// resync
+		return false
+	}
+
+	equal, err := h.opts.StatusEqualE(oldObj, newObj)
+	if err != nil {
+		klog.Errorln(err)
+		return false
+	}
+	if !equal {
+		return false
+	}
+
+	equal, err = nonStatusEqual(oldObj, newObj)
+	if err != nil {
+		klog.Errorln(err)
+		return false
+	}
+	return equal
+}
+
+// nonStatusEqual reports whether old and new are equal apart from their status,
+// resourceVersion and managedFields.
+func nonStatusEqual(old, new interface{}) (bool, error) {
+	oldMap, err := toUnstructuredMap(old)
+	if err != nil {
+		return false, err
+	}
+	newMap, err := toUnstructuredMap(new)
+	if err != nil {
+		return false, err
+	}
+	for _, m := range []map[string]interface{}{oldMap, newMap} {
+		delete(m, "status")
+		unstructured.RemoveNestedField(m, "metadata", "resourceVersion")
+		unstructured.RemoveNestedField(m, "metadata", "managedFields")
+	}
+	return reflect.DeepEqual(oldMap, newMap), nil
+}
+
+// toUnstructuredMap returns the json representation of o. For unstructured objects,
+// only the top level and metadata maps are copied.
+func toUnstructuredMap(o interface{}) (map[string]interface{}, error) {
+	u, ok := o.(*unstructured.Unstructured)
+	if !ok {
+		return runtime.DefaultUnstructuredConverter.ToUnstructured(o)
+	}
+	m := make(map[string]interface{}, len(u.Object))
+	for k, v := range u.Object {
+		m[k] = v
+	}
+	if md, ok := m["metadata"].(map[string]interface{}); ok {
+		cp := make(map[string]interface{}, len(md))
+		for k, v := range md {
+			cp[k] = v
+		}
+		m["metadata"] = cp
+	}
+	return m, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"testing"
+
+	apps "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestFilterStatusOnlyUpdates(t *testing.T) {
+	withRV := func(o interface{}, rv string) interface{} {
+		switch obj := o.(type) {
+		case *apps.Deployment:
+			out := obj.DeepCopy()
+			out.ResourceVersion = rv
+			return out
+		case *unstructured.Unstructured:
+			out := obj.DeepCopy()
+			out.SetResourceVersion(rv)
+			return out
+		}
+		panic("unknown object")
+	}
+	scaled := d1ConditionTimeUpdated.DeepCopy()
+	scaled.Spec.Replicas = nil
+
+	tests := []struct {
+		name string
+		old  interface{}
+		new  interface{}
+		want bool
+	}{
+		{
+			name: "Condition Time Modified",
+			old:  withRV(d1, "1"),
+			new:  withRV(d1ConditionTimeUpdated, "2"),
+			want: false,
+		},
+		{
+			name: "Condition Status Modified",
+			old:  withRV(d1, "1"),
+			new:  withRV(d1ConditionStatusUpdated, "2"),
+			want: true,
+		},
+		{
+			name: "Spec Modified",
+			old:  withRV(d1, "1"),
+			new:  withRV(scaled, "2"),
+			want: true,
+		},
+		{
+			name: "Resync",
+			old:  withRV(d1, "1"),
+			new:  withRV(d1, "1"),
+			want: true,
+		},
+		{
+			name: "Unstructured Condition Time Modified",
+			old:  withRV(toJSON(a1), "1"),
+			new:  withRV(toJSON(a1ConditionTimeUpdated), "2"),
+			want: false,
+		},
+		{
+			name: "Unstructured Condition Status Modified",
+			old:  withRV(toJSON(a1), "1"),
+			new:  withRV(toJSON(a1ConditionStatusUpdated), "2"),
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got bool
+			h := FilterStatusOnlyUpdates(cache.ResourceEventHandlerFuncs{
+				UpdateFunc: func(oldObj, newObj interface{}) {
+					got = true
+				},
+			}, EqualOptions{})
+			h.OnUpdate(tt.old, tt.new)
+			if got != tt.want {
+				t.Errorf("OnUpdate() called = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
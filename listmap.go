@@ -0,0 +1,333 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/kube-openapi/pkg/util/proto"
+)
+
+type listMapMatcher struct {
+	paths pathMatcher
+	keys  []string
+}
+
+func newListMapMatchers(listMapKeys map[string][]string) ([]listMapMatcher, error) {
+	out := make([]listMapMatcher, 0, len(listMapKeys))
+	for _, path := range unionKeys(listMapKeys) {
+		keys := listMapKeys[path]
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("no list map keys for path %q", path)
+		}
+		m, err := newPathMatcher([]string{path})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, listMapMatcher{paths: m, keys: keys})
+	}
+	return out, nil
+}
+
+// listMapKeys returns the keys of the list at path, nil if it is not a list map.
+func (d *differ) listMapKeys(path string) []string {
+	for _, m := range d.listMaps {
+		if m.paths.MatchExact(path) {
+			return m.keys
+		}
+	}
+	return nil
+}
+
+// formatListMapKey returns the key of a list map item, eg, name=db-0 or ip=10.0.0.1,hostname=
+// ok is false if the item has none of the keys.
+func formatListMapKey(keys []string, field func(string) (interface{}, bool)) (string, bool) {
+	parts := make([]string, 0, len(keys))
+	found := false
+	for _, k := range keys {
+		v, ok := field(k)
+		if ok && v != nil {
+			found = true
+			parts = append(parts, fmt.Sprintf("%s=%v", k, v))
+		} else {
+			parts = append(parts, k+"=")
+		}
+	}
+	return strings.Join(parts, ","), found
+}
+
+// indexListMap indexes list items by their key. ok is false if an item has no key or
+// a key is duplicated, in which case the list can't be compared as a list map.
+func indexListMap(n int, key func(i int) (string, bool)) (map[string]int, bool) {
+	index := make(map[string]int, n)
+	for i := 0; i < n; i++ {
+		k, ok := key(i)
+		if !ok {
+			return nil, false
+		}
+		if _, dup := index[k]; dup {
+			return nil, false
+		}
+		index[k] = i
+	}
+	return index, true
+}
+
+func sortedIndexKeys(old, nu map[string]int) []string {
+	keys := make([]string, 0, len(old)+len(nu))
+	for k := range old {
+		keys = append(keys, k)
+	}
+	for k := range nu {
+		if _, ok := old[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// compareListMap compares unstructured lists by the keys of their items, ignoring order.
+// It returns false without recording differences if the lists are not valid list maps.
+func (d *differ) compareListMap(path string, keys []string, old, nu []interface{}) (bool, error) {
+	key := func(list []interface{}) func(int) (string, bool) {
+		return func(i int) (string, bool) {
+			item, ok := list[i].(map[string]interface{})
+			if !ok {
+				return "", false
+			}
+			return formatListMapKey(keys, func(k string) (interface{}, bool) {
+				v, ok := item[k]
+				return v, ok
+			})
+		}
+	}
+	oldIndex, ok := indexListMap(len(old), key(old))
+	if !ok {
+		return false, nil
+	}
+	nuIndex, ok := indexListMap(len(nu), key(nu))
+	if !ok {
+		return false, nil
+	}
+
+	for _, k := range sortedIndexKeys(oldIndex, nuIndex) {
+		p := fmt.Sprintf("%s[%s]", path, k)
+		if d.ignore.Match(p) {
+			continue
+		}
+		oi, oldOk := oldIndex[k]
+		ni, newOk := nuIndex[k]
+		switch {
+		case !oldOk:
+			d.add(FieldDiff{Path: p, Op: DiffAdded, New: nu[ni]})
+		case !newOk:
+			d.add(FieldDiff{Path: p, Op: DiffRemoved, Old: old[oi]})
+		default:
+			if err := d.compareValues(p, old[oi], nu[ni]); err != nil {
+				return true, err
+			}
+		}
+	}
+	return true, nil
+}
+
+// compareTypedListMap is the typed variant of compareListMap. Items are structs
+// and keys are json field names.
+func (d *differ) compareTypedListMap(path string, keys []string, old, nu reflect.Value) bool {
+	key := func(list reflect.Value) func(int) (string, bool) {
+		return func(i int) (string, bool) {
+			item := list.Index(i)
+			for item.Kind() == reflect.Ptr || item.Kind() == reflect.Interface {
+				if item.IsNil() {
+					return "", false
+				}
+				item = item.Elem()
+			}
+			if item.Kind() != reflect.Struct {
+				return "", false
+			}
+			info := getStructInfo(item.Type())
+			return formatListMapKey(keys, func(k string) (interface{}, bool) {
+				for _, f := range info.fields {
+					if f.name == k {
+						return item.FieldByIndex(f.index).Interface(), true
+					}
+				}
+				return nil, false
+			})
+		}
+	}
+	oldIndex, ok := indexListMap(old.Len(), key(old))
+	if !ok {
+		return false
+	}
+	nuIndex, ok := indexListMap(nu.Len(), key(nu))
+	if !ok {
+		return false
+	}
+
+	for _, k := range sortedIndexKeys(oldIndex, nuIndex) {
+		p := fmt.Sprintf("%s[%s]", path, k)
+		if d.ignore.Match(p) {
+			continue
+		}
+		oi, oldOk := oldIndex[k]
+		ni, newOk := nuIndex[k]
+		switch {
+		case !oldOk:
+			d.add(FieldDiff{Path: p, Op: DiffAdded, New: nu.Index(ni).Interface()})
+		case !newOk:
+			d.add(FieldDiff{Path: p, Op: DiffRemoved, Old: old.Index(oi).Interface()})
+		default:
+			d.compareTyped(p, old.Index(oi), nu.Index(ni))
+		}
+	}
+	return true
+}
+
+// compareListSet compares lists of scalars ignoring order and duplicates.
+func (d *differ) compareListSet(path string, old, nu []interface{}) {
+	index := func(list []interface{}) map[string]int {
+		out := make(map[string]int, len(list))
+		for i, item := range list {
+			out[fmt.Sprintf("%v", item)] = i
+		}
+		return out
+	}
+	oldIndex, nuIndex := index(old), index(nu)
+	for _, k := range sortedIndexKeys(oldIndex, nuIndex) {
+		p := fmt.Sprintf("%s[=%s]", path, k)
+		if d.ignore.Match(p) {
+			continue
+		}
+		oi, oldOk := oldIndex[k]
+		ni, newOk := nuIndex[k]
+		switch {
+		case !oldOk:
+			d.add(FieldDiff{Path: p, Op: DiffAdded, New: nu[ni]})
+		case !newOk:
+			d.add(FieldDiff{Path: p, Op: DiffRemoved, Old: old[oi]})
+		}
+	}
+}
+
+func typedListItems(list reflect.Value) []interface{} {
+	out := make([]interface{}, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		out = append(out, list.Index(i).Interface())
+	}
+	return out
+}
+
+// ListMapKeysFromModels returns the list map keys of the status lists of a kind, as
+// published in its OpenAPI schema by x-kubernetes-list-map-keys or x-kubernetes-patch-merge-key.
+// The result can be used as EqualOptions.ListMapKeys.
+func ListMapKeysFromModels(models proto.Models, gvk schema.GroupVersionKind) (map[string][]string, error) {
+	model := lookupModel(models, gvk)
+	if model == nil {
+		return nil, fmt.Errorf("no OpenAPI schema found for %v", gvk)
+	}
+	out := map[string][]string{}
+	kind, ok := resolveSchema(model).(*proto.Kind)
+	if !ok {
+		return out, nil
+	}
+	if status, ok := kind.Fields["status"]; ok {
+		collectListMapKeys("status", status, out, map[string]bool{})
+	}
+	return out, nil
+}
+
+func lookupModel(models proto.Models, gvk schema.GroupVersionKind) proto.Schema {
+	for _, name := range models.ListModels() {
+		model := models.LookupModel(name)
+		if model == nil {
+			continue
+		}
+		gvks, _ := model.GetExtensions()["x-kubernetes-group-version-kind"].([]interface{})
+		for _, item := range gvks {
+			if gvkExtension(item) == gvk {
+				return model
+			}
+		}
+	}
+	return nil
+}
+
+func gvkExtension(v interface{}) schema.GroupVersionKind {
+	get := func(key string) string {
+		switch m := v.(type) {
+		case map[string]interface{}:
+			s, _ := m[key].(string)
+			return s
+		case map[interface{}]interface{}:
+			s, _ := m[key].(string)
+			return s
+		}
+		return ""
+	}
+	return schema.GroupVersionKind{Group: get("group"), Version: get("version"), Kind: get("kind")}
+}
+
+func resolveSchema(s proto.Schema) proto.Schema {
+	for {
+		ref, ok := s.(proto.Reference)
+		if !ok || ref.SubSchema() == nil {
+			return s
+		}
+		s = ref.SubSchema()
+	}
+}
+
+func collectListMapKeys(path string, s proto.Schema, out map[string][]string, visiting map[string]bool) {
+	if ref, ok := s.(proto.Reference); ok {
+		name := ref.Reference()
+		if visiting[name] {
+			// recursive schema
+			return
+		}
+		visiting[name] = true
+		defer delete(visiting, name)
+		if keys := listMapKeysFromExtensions(ref.GetExtensions()); len(keys) > 0 {
+			out[path] = keys
+		}
+		collectListMapKeys(path, ref.SubSchema(), out, visiting)
+		return
+	}
+
+	switch t := s.(type) {
+	case *proto.Kind:
+		for _, name := range t.Keys() {
+			collectListMapKeys(path+"."+name, t.Fields[name], out, visiting)
+		}
+	case *proto.Map:
+		collectListMapKeys(path+".*", t.SubType, out, visiting)
+	case *proto.Array:
+		if keys := listMapKeysFromExtensions(t.GetExtensions()); len(keys) > 0 {
+			out[path] = keys
+		}
+		collectListMapKeys(path+"[*]", t.SubType, out, visiting)
+	}
+}
+
+func listMapKeysFromExtensions(ext map[string]interface{}) []string {
+	if listType, _ := ext["x-kubernetes-list-type"].(string); listType == "map" {
+		var keys []string
+		items, _ := ext["x-kubernetes-list-map-keys"].([]interface{})
+		for _, item := range items {
+			if k, ok := item.(string); ok {
+				keys = append(keys, k)
+			}
+		}
+		return keys
+	}
+	if strategy, _ := ext["x-kubernetes-patch-strategy"].(string); strings.Contains(strategy, "merge") {
+		if k, ok := ext["x-kubernetes-patch-merge-key"].(string); ok {
+			return []string{k}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/kube-openapi/pkg/util/proto"
+)
+
+func TestStatusCompare_ListMapKeys(t *testing.T) {
+	opts := EqualOptions{
+		ListMapKeys: map[string][]string{"status.containerStatuses": {"name"}},
+	}
+
+	tests := []struct {
+		name  string
+		opts  EqualOptions
+		old   interface{}
+		new   interface{}
+		paths []string
+	}{
+		{
+			name: "Typed Reordered By Index",
+			old:  pod("app", "sidecar"),
+			new:  pod("sidecar", "app"),
+			paths: []string{
+				"status.containerStatuses[0].image",
+				"status.containerStatuses[0].name",
+				"status.containerStatuses[1].image",
+				"status.containerStatuses[1].name",
+			},
+		},
+		{
+			name:  "Typed Reordered",
+			opts:  opts,
+			old:   pod("app", "sidecar"),
+			new:   pod("sidecar", "app"),
+			paths: nil,
+		},
+		{
+			name:  "Typed Added",
+			opts:  opts,
+			old:   pod("app"),
+			new:   pod("sidecar", "app"),
+			paths: []string{"status.containerStatuses[name=sidecar]"},
+		},
+		{
+			name:  "Unstructured Reordered",
+			opts:  opts,
+			old:   toUnstructured(pod("app", "sidecar")),
+			new:   toUnstructured(pod("sidecar", "app")),
+			paths: nil,
+		},
+		{
+			name:  "Unstructured Modified",
+			opts:  opts,
+			old:   toUnstructured(pod("app", "sidecar")),
+			new:   toUnstructured(pod("sidecar-broken", "app")),
+			paths: []string{"status.containerStatuses[name=sidecar]", "status.containerStatuses[name=sidecar-broken]"},
+		},
+		{
+			name: "Unstructured Multiple Keys",
+			opts: EqualOptions{
+				ListMapKeys: map[string][]string{"status.loadBalancer.ingress": {"ip", "hostname"}},
+			},
+			old: &unstructured.Unstructured{Object: map[string]interface{}{
+				"status": map[string]interface{}{"loadBalancer": map[string]interface{}{"ingress": []interface{}{
+					map[string]interface{}{"ip": "10.0.0.1"},
+					map[string]interface{}{"hostname": "lb.example.com"},
+				}}},
+			}},
+			new: &unstructured.Unstructured{Object: map[string]interface{}{
+				"status": map[string]interface{}{"loadBalancer": map[string]interface{}{"ingress": []interface{}{
+					map[string]interface{}{"hostname": "lb.example.com"},
+					map[string]interface{}{"ip": "10.0.0.2"},
+				}}},
+			}},
+			paths: []string{"status.loadBalancer.ingress[ip=10.0.0.1,hostname=]", "status.loadBalancer.ingress[ip=10.0.0.2,hostname=]"},
+		},
+		{
+			name: "Unstructured List Set",
+			opts: EqualOptions{ListSets: []string{"status.addresses"}},
+			old: &unstructured.Unstructured{Object: map[string]interface{}{
+				"status": map[string]interface{}{"addresses": []interface{}{"10.0.0.1", "10.0.0.2"}},
+			}},
+			new: &unstructured.Unstructured{Object: map[string]interface{}{
+				"status": map[string]interface{}{"addresses": []interface{}{"10.0.0.3", "10.0.0.1"}},
+			}},
+			paths: []string{"status.addresses[=10.0.0.2]", "status.addresses[=10.0.0.3]"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.opts.StatusCompare(tt.old, tt.new)
+			if err != nil {
+				t.Fatalf("StatusCompare() error = %v", err)
+			}
+			var paths []string
+			for _, f := range got.Fields {
+				paths = append(paths, f.Path)
+			}
+			if !reflect.DeepEqual(paths, tt.paths) {
+				t.Errorf("StatusCompare() paths = %v, want %v", paths, tt.paths)
+			}
+		})
+	}
+}
+
+type fakeModels map[string]proto.Schema
+
+func (m fakeModels) LookupModel(name string) proto.Schema {
+	return m[name]
+}
+
+func (m fakeModels) ListModels() []string {
+	return unionKeys(map[string]proto.Schema(m))
+}
+
+func TestListMapKeysFromModels(t *testing.T) {
+	primitive := &proto.Primitive{Type: "string"}
+	containerStatus := &proto.Kind{
+		Fields: map[string]proto.Schema{"name": primitive, "image": primitive},
+	}
+	models := fakeModels{
+		"io.k8s.api.core.v1.Pod": &proto.Kind{
+			BaseSchema: proto.BaseSchema{
+				Extensions: map[string]interface{}{
+					"x-kubernetes-group-version-kind": []interface{}{
+						map[interface{}]interface{}{"group": "", "version": "v1", "kind": "Pod"},
+					},
+				},
+			},
+			Fields: map[string]proto.Schema{
+				"status": &proto.Kind{
+					Fields: map[string]proto.Schema{
+						"containerStatuses": &proto.Array{
+							BaseSchema: proto.BaseSchema{
+								Extensions: map[string]interface{}{
+									"x-kubernetes-list-type":     "map",
+									"x-kubernetes-list-map-keys": []interface{}{"name"},
+								},
+							},
+							SubType: containerStatus,
+						},
+						"conditions": &proto.Array{
+							BaseSchema: proto.BaseSchema{
+								Extensions: map[string]interface{}{
+									"x-kubernetes-patch-strategy":  "merge",
+									"x-kubernetes-patch-merge-key": "type",
+								},
+							},
+							SubType: &proto.Kind{},
+						},
+						"hostIPs": &proto.Array{SubType: primitive},
+					},
+				},
+			},
+		},
+	}
+
+	got, err := ListMapKeysFromModels(models, schema.GroupVersionKind{Version: "v1", Kind: "Pod"})
+	if err != nil {
+		t.Fatalf("ListMapKeysFromModels() error = %v", err)
+	}
+	want := map[string][]string{
+		"status.containerStatuses": {"name"},
+		"status.conditions":        {"type"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListMapKeysFromModels() = %v, want %v", got, want)
+	}
+
+	if _, err := ListMapKeysFromModels(models, schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}); err == nil {
+		t.Errorf("ListMapKeysFromModels() expected error for unknown kind")
+	}
+}
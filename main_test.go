@@ -327,6 +327,38 @@ func TestStatusEqual(t *testing.T) {
 			},
 			want: false,
 		},
+		{
+			name: "Mixed Same",
+			args: args{
+				old: d1,
+				new: toJSON(a1),
+			},
+			want: true,
+		},
+		{
+			name: "Mixed Missing Conditions",
+			args: args{
+				old: toJSON(a1MissingCondition),
+				new: d1MissingCondition,
+			},
+			want: true,
+		},
+		{
+			name: "Mixed Condition Time Modified",
+			args: args{
+				old: d1,
+				new: toJSON(a1ConditionTimeUpdated),
+			},
+			want: true,
+		},
+		{
+			name: "Mixed Condition Status Modified",
+			args: args{
+				old: toJSON(a1),
+				new: d1ConditionStatusUpdated,
+			},
+			want: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -348,3 +380,26 @@ func toJSON(s string) runtime.Object {
 	}
 	return out
 }
+
+// status returns an unstructured object whose status has the given keys and values.
+func status(kv ...interface{}) *unstructured.Unstructured {
+	m := map[string]interface{}{}
+	for i := 0; i < len(kv); i += 2 {
+		m[kv[i].(string)] = kv[i+1]
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{"status": m}}
+}
+
+// pod returns a typed Pod with a container status for each name, in order.
+// The container named sidecar-broken is not ready.
+func pod(names ...string) *core.Pod {
+	p := &core.Pod{}
+	for _, name := range names {
+		p.Status.ContainerStatuses = append(p.Status.ContainerStatuses, core.ContainerStatus{
+			Name:  name,
+			Ready: name != "sidecar-broken",
+			Image: name + ":v1",
+		})
+	}
+	return p
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// isManifestArg reports whether a command argument names manifests, ie,
+// "-" for stdin or an existing file or directory, instead of a RESOURCE/NAME.
+// Arguments that look like paths are manifests even if they don't exist, so
+// that a mistyped file name is reported as missing.
+func isManifestArg(arg string) bool {
+	if arg == "-" {
+		return true
+	}
+	if _, err := os.Stat(arg); err == nil {
+		return true
+	}
+	switch strings.ToLower(filepath.Ext(arg)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	if strings.Count(arg, "/") > 1 || strings.HasPrefix(arg, "/") || strings.HasPrefix(arg, ".") {
+		return true
+	}
+	return filepath.Separator != '/' && strings.ContainsRune(arg, filepath.Separator)
+}
+
+// isDir reports whether arg is an existing directory.
+func isDir(arg string) bool {
+	fi, err := os.Stat(arg)
+	return err == nil && fi.IsDir()
+}
+
+// readManifest reads a manifest file, or stdin if filename is "-".
+func readManifest(filename string, stdin io.Reader) ([]byte, error) {
+	if filename == "-" {
+		return ioutil.ReadAll(stdin)
+	}
+	return ioutil.ReadFile(filename)
+}
+
+// loadManifests returns the objects of a manifest file or of every .yaml, .yml and .json
+// file under a directory.
+func loadManifests(path string, stdin io.Reader) ([]*unstructured.Unstructured, error) {
+	fi, err := os.Stat(path)
+	if path == "-" || (err == nil && !fi.IsDir()) {
+		data, err := readManifest(path, stdin)
+		if err != nil {
+			return nil, err
+		}
+		objects, err := decodeManifests(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return objects, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []*unstructured.Unstructured
+	err = filepath.Walk(path, func(filename string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(filename)) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+		items, err := loadManifests(filename, nil)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, items...)
+		return nil
+	})
+	return objects, err
+}
+
+// decodeManifests decodes a YAML or JSON stream of objects, eg, the output of kubectl get -o yaml.
+// Multiple YAML documents and the items of List objects are returned as separate objects.
+func decodeManifests(data []byte) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			return objects, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		items, err := decodeManifest(doc)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, items...)
+	}
+}
+
+// decodeManifest decodes a single YAML or JSON document into its objects.
+func decodeManifest(data []byte) ([]*unstructured.Unstructured, error) {
+	data, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return nil, nil
+	}
+	obj, _, err := unstructured.UnstructuredJSONScheme.Decode(data, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch o := obj.(type) {
+	case *unstructured.Unstructured:
+		return []*unstructured.Unstructured{o}, nil
+	case *unstructured.UnstructuredList:
+		objects := make([]*unstructured.Unstructured, 0, len(o.Items))
+		for i := range o.Items {
+			objects = append(objects, &o.Items[i])
+		}
+		return objects, nil
+	}
+	return nil, fmt.Errorf("unexpected object %T", obj)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/clock"
+)
+
+// ConditionMerger sets the transition times of rebuilt condition lists.
+// The zero value uses the real clock.
+type ConditionMerger struct {
+	// Clock returns the transition time of changed conditions. Defaults to clock.RealClock.
+	Clock clock.PassiveClock
+}
+
+// MergeConditions is like ConditionMerger.MergeConditions using the real clock.
+func MergeConditions(existing, desired interface{}) error {
+	return ConditionMerger{}.MergeConditions(existing, desired)
+}
+
+// MergeConditions sets the lastTransitionTime of the desired conditions: conditions whose
+// type exists in existing with the same status keep the existing lastTransitionTime,
+// new conditions and conditions whose status changed get the current time.
+// Existing and desired are typed condition slices, eg, []metav1.Condition, or unstructured
+// condition lists. Desired is modified in place; typed slices may be passed by value or pointer.
+// Types that are duplicated in existing are treated as new.
+func (m ConditionMerger) MergeConditions(existing, desired interface{}) error {
+	v := indirect(reflect.ValueOf(desired))
+	if v.Kind() != reflect.Slice {
+		return fmt.Errorf("%w: desired conditions of type %T are not a list", ErrStatusDecode, desired)
+	}
+	c := m.Clock
+	if c == nil {
+		c = clock.RealClock{}
+	}
+	now := c.Now().UTC().Format(time.RFC3339)
+	if err := mergeTransitionTimes(existing, v, &now); err != nil {
+		return fmt.Errorf("%w: %v", ErrStatusDecode, err)
+	}
+	return nil
+}
+
+// mergeTransitionTimes sets the lastTransitionTime of the desired conditions to the one of
+// the existing condition of the same type, if both have the same status.
+// Other conditions get the time now, or are left as is if now is nil.
+func mergeTransitionTimes(existing interface{}, desired reflect.Value, now *string) error {
+	if desired.Kind() != reflect.Slice {
+		return nil
+	}
+	conditions, err := decodeConditions(existing)
+	if err != nil {
+		return err
+	}
+	byType := conditionsByType(conditions)
+
+	for i := 0; i < desired.Len(); i++ {
+		item := indirect(desired.Index(i))
+		c, ok := conditionFields(item)
+		if !ok {
+			continue
+		}
+		var t interface{}
+		if old := byType[conditionType(c)]; len(old) == 1 && fmt.Sprint(old[0]["status"]) == fmt.Sprint(c["status"]) {
+			t = old[0]["lastTransitionTime"]
+		}
+		if t == nil && now != nil {
+			t = *now
+		}
+		if t == nil {
+			continue
+		}
+		if err := setConditionField(item, "lastTransitionTime", t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// conditionFields returns the type and status of a typed or unstructured condition.
+func conditionFields(item reflect.Value) (map[string]interface{}, bool) {
+	switch item.Kind() {
+	case reflect.Map:
+		c, ok := item.Interface().(map[string]interface{})
+		return c, ok
+	case reflect.Struct:
+		out := map[string]interface{}{}
+		for _, name := range []string{"type", "status"} {
+			f, ok := jsonField(item.Type(), name)
+			if !ok {
+				return nil, false
+			}
+			out[name] = item.FieldByIndex(f.index).Interface()
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+// setConditionField sets a field of a typed or unstructured condition to the json value v.
+func setConditionField(item reflect.Value, name string, v interface{}) error {
+	switch item.Kind() {
+	case reflect.Map:
+		item.Interface().(map[string]interface{})[name] = v
+	case reflect.Struct:
+		f, ok := jsonField(item.Type(), name)
+		if !ok {
+			return nil
+		}
+		field := item.FieldByIndex(f.index)
+		if !field.CanAddr() {
+			return fmt.Errorf("condition of type %v can't be modified", item.Type())
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(data, field.Addr().Interface())
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+)
+
+func TestMergeConditions_Typed(t *testing.T) {
+	then := metav1.NewTime(time.Date(2021, 5, 8, 19, 3, 45, 0, time.UTC))
+	now := metav1.NewTime(then.Add(time.Hour))
+	rebuilt := metav1.NewTime(then.Add(2 * time.Hour))
+
+	existing := []metav1.Condition{
+		{Type: "Ready", Status: metav1.ConditionTrue, LastTransitionTime: then, Reason: "Running"},
+		{Type: "Degraded", Status: metav1.ConditionFalse, LastTransitionTime: then},
+	}
+	desired := []metav1.Condition{
+		{Type: "Ready", Status: metav1.ConditionTrue, LastTransitionTime: rebuilt, Reason: "Upgrading"},
+		{Type: "Degraded", Status: metav1.ConditionTrue, LastTransitionTime: rebuilt},
+		{Type: "Upgrading", Status: metav1.ConditionTrue},
+	}
+	m := ConditionMerger{Clock: clock.NewFakePassiveClock(now.Time)}
+	if err := m.MergeConditions(existing, &desired); err != nil {
+		t.Fatalf("MergeConditions() error = %v", err)
+	}
+	want := []metav1.Time{then, now, now}
+	for i, c := range desired {
+		if !c.LastTransitionTime.Equal(&want[i]) {
+			t.Errorf("condition %s lastTransitionTime = %v, want %v", c.Type, c.LastTransitionTime, want[i])
+		}
+	}
+	if desired[0].Reason != "Upgrading" {
+		t.Errorf("condition Ready reason = %v, want Upgrading", desired[0].Reason)
+	}
+}
+
+func TestMergeConditions_Unstructured(t *testing.T) {
+	existing := []interface{}{
+		map[string]interface{}{"type": "Ready", "status": "True", "lastTransitionTime": "2021-05-08T19:03:45Z"},
+		map[string]interface{}{"type": "Synced", "status": "True", "lastTransitionTime": "2021-05-08T19:03:45Z"},
+		map[string]interface{}{"type": "Synced", "status": "False", "lastTransitionTime": "2021-05-08T19:03:45Z"},
+	}
+	desired := []interface{}{
+		map[string]interface{}{"type": "Ready", "status": "True"},
+		map[string]interface{}{"type": "Synced", "status": "True"},
+	}
+	m := ConditionMerger{Clock: clock.NewFakePassiveClock(time.Date(2021, 5, 8, 20, 3, 45, 0, time.UTC))}
+	if err := m.MergeConditions(existing, desired); err != nil {
+		t.Fatalf("MergeConditions() error = %v", err)
+	}
+	want := []interface{}{
+		map[string]interface{}{"type": "Ready", "status": "True", "lastTransitionTime": "2021-05-08T19:03:45Z"},
+		// duplicated in existing
+		map[string]interface{}{"type": "Synced", "status": "True", "lastTransitionTime": "2021-05-08T20:03:45Z"},
+	}
+	if !reflect.DeepEqual(desired, want) {
+		t.Errorf("MergeConditions() = %v, want %v", desired, want)
+	}
+}
+
+func TestMergeConditions_NotAList(t *testing.T) {
+	if err := MergeConditions(nil, "Ready"); !errors.Is(err, ErrStatusDecode) {
+		t.Errorf("MergeConditions() error = %v, want %v", err, ErrStatusDecode)
+	}
+}
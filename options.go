@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	clientsetscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+// DefaultConditionCompareFields are the condition fields compared when
+// EqualOptions.ConditionCompareFields is empty.
+var DefaultConditionCompareFields = []string{"type", "status", "observedGeneration"}
+
+// DefaultConditionPaths are the condition lists compared by type when
+// EqualOptions.ConditionPaths is empty.
+var DefaultConditionPaths = []string{"status.conditions"}
+
+// EmptyEquivalence controls which missing, null and empty status fields are equal.
+// Typed values are compared as encoding/json would serialize them, ie, empty
+// omitempty fields are missing and nil slices, maps and pointers are null.
+type EmptyEquivalence string
+
+const (
+	// EmptyStrict treats missing, null and empty fields as different from each other.
+	// It is named Strict in flags and policy configs.
+	EmptyStrict EmptyEquivalence = ""
+	// EmptyNullMissing treats null fields as missing. Empty lists and maps are still different.
+	EmptyNullMissing EmptyEquivalence = "NullMissing"
+	// EmptyAll treats missing, null, empty list and empty map fields as equal.
+	EmptyAll EmptyEquivalence = "All"
+)
+
+// emptyEquivalenceNames are the names accepted by parseEmptyEquivalence.
+var emptyEquivalenceNames = []string{"Strict", string(EmptyNullMissing), string(EmptyAll)}
+
+// parseEmptyEquivalence returns the EmptyEquivalence named s. Both "" and "Strict" are EmptyStrict.
+func parseEmptyEquivalence(s string) (EmptyEquivalence, error) {
+	switch s {
+	case "", "Strict":
+		return EmptyStrict, nil
+	case string(EmptyNullMissing), string(EmptyAll):
+		return EmptyEquivalence(s), nil
+	}
+	return EmptyStrict, fmt.Errorf("invalid empty equivalence %q, expected Strict, NullMissing or All", s)
+}
+
+// EqualOptions controls how two statuses are compared.
+// The zero value compares conditions by type, status and observedGeneration
+// and every other status field as is.
+type EqualOptions struct {
+	// ConditionCompareFields lists the condition fields that are compared.
+	// "*" selects every field. Defaults to DefaultConditionCompareFields.
+	ConditionCompareFields []string
+	// ConditionIgnoreFields lists condition fields that are never compared,
+	// even if selected by ConditionCompareFields.
+	ConditionIgnoreFields []string
+	// IgnoreConditionObservedGeneration treats changes in the observedGeneration
+	// of a condition as insignificant. Other compared fields of the condition still count.
+	IgnoreConditionObservedGeneration bool
+	// ConditionPaths lists the paths of condition lists, eg, status.components[*].conditions
+	// Condition lists are compared by type using the condition field rules above.
+	// Defaults to DefaultConditionPaths.
+	ConditionPaths []string
+	// DetectConditions compares every list whose items all have a type and a status as a condition list.
+	DetectConditions bool
+	// ListMapKeys declares lists whose items are identified by the given keys, eg,
+	// {"status.containerStatuses": {"name"}}. These lists are compared ignoring order.
+	// Lists with items missing all keys or with duplicated keys are compared by index.
+	// See ListMapKeysFromModels to read them from OpenAPI schemas.
+	ListMapKeys map[string][]string
+	// ListSets lists the paths of lists of scalars that are compared ignoring order
+	// and duplicates, eg, status.addresses
+	ListSets []string
+	// IgnorePaths lists status field paths that are never compared, eg,
+	// status.lastHeartbeatTime, status.stats.* or status.members[*].observedAt
+	// Ignoring a field also ignores every field nested under it.
+	IgnorePaths []string
+	// EmptyEquivalence controls which missing, null and empty fields are equal.
+	// Defaults to EmptyStrict.
+	EmptyEquivalence EmptyEquivalence
+	// SemanticValues compares unstructured values that parse as times, quantities
+	// or int-or-strings semantically, eg, "1000m" equals "1".
+	// Typed values of these types are always compared semantically.
+	SemanticValues bool
+	// ValueTypes declares the type of unstructured fields by path, eg,
+	// {"status.capacity.*": QuantityValue}. Declared fields are compared semantically
+	// even if SemanticValues is not set.
+	ValueTypes map[string]ValueType
+	// Scheme resolves the Go type of unstructured objects by apiVersion and kind, so that
+	// their quantities and int-or-strings are compared and hashed like in the typed object.
+	// Defaults to the client-go scheme.
+	Scheme *runtime.Scheme
+}
+
+func (opts EqualOptions) scheme() *runtime.Scheme {
+	if opts.Scheme == nil {
+		return clientsetscheme.Scheme
+	}
+	return opts.Scheme
+}
+
+func (opts EqualOptions) compareConditionField(field string) bool {
+	if opts.IgnoreConditionObservedGeneration && field == "observedGeneration" {
+		return false
+	}
+	for _, f := range opts.ConditionIgnoreFields {
+		if f == field {
+			return false
+		}
+	}
+	fields := opts.ConditionCompareFields
+	if len(fields) == 0 {
+		fields = DefaultConditionCompareFields
+	}
+	for _, f := range fields {
+		if f == "*" || f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// equivalentToMissing reports whether v is treated like a missing field.
+func (opts EqualOptions) equivalentToMissing(v interface{}) bool {
+	switch opts.EmptyEquivalence {
+	case EmptyNullMissing:
+		return isNull(v)
+	case EmptyAll:
+		return isNull(v) || isEmptyCollection(v)
+	}
+	return false
+}
+
+func isNull(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// isEmptyCollection reports whether v is an empty list or map, or a map whose entries
+// are all null or empty, eg, {"x": {"y": []}}, so that EmptyAll is transitive.
+func isEmptyCollection(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map:
+		iter := rv.MapRange()
+		for iter.Next() {
+			if e := iter.Value().Interface(); !isNull(e) && !isEmptyCollection(e) {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice, reflect.Array:
+		return rv.Len() == 0
+	}
+	return false
+}
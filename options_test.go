@@ -0,0 +1,335 @@
+package main
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var a1ConditionReasonUpdated = `kind: Deployment
+apiVersion: apps/v1
+metadata:
+  name: d1
+  namespace: demo
+spec:
+  replicas: 3
+status:
+  observedGeneration: 2
+  replicas: 3
+  updatedReplicas: 3
+  readyReplicas: 3
+  availableReplicas: 3
+  conditions:
+  - type: Available
+    status: 'True'
+    lastUpdateTime: '2021-05-08T19:03:45Z'
+    lastTransitionTime: '2021-05-08T19:03:45Z'
+    reason: MinimumReplicasAvailable
+    message: Deployment has minimum availability.
+  - type: Progressing
+    status: 'True'
+    lastUpdateTime: '2021-05-08T19:03:45Z'
+    lastTransitionTime: '2021-05-08T19:03:45Z'
+    reason: ReplicaSetUpdated
+    message: ReplicaSet "d1" is progressing.
+`
+
+var a1ReplicasUpdated = `kind: Deployment
+apiVersion: apps/v1
+metadata:
+  name: d1
+  namespace: demo
+spec:
+  replicas: 3
+status:
+  observedGeneration: 2
+  replicas: 3
+  updatedReplicas: 3
+  readyReplicas: 2
+  availableReplicas: 2
+  conditions:
+  - type: Available
+    status: 'True'
+    lastUpdateTime: '2021-05-08T19:03:45Z'
+    lastTransitionTime: '2021-05-08T19:03:45Z'
+    reason: MinimumReplicasAvailable
+    message: Deployment has minimum availability.
+  - type: Progressing
+    status: 'True'
+    lastUpdateTime: '2021-05-08T19:03:45Z'
+    lastTransitionTime: '2021-05-08T19:03:45Z'
+    reason: NewReplicaSetAvailable
+    message: ReplicaSet "d1" has successfully progressed.
+`
+
+func TestEqualOptions_StatusEqual(t *testing.T) {
+	type args struct {
+		old interface{}
+		new interface{}
+	}
+	tests := []struct {
+		name string
+		opts EqualOptions
+		args args
+		want bool
+	}{
+		{
+			name: "Default Reason Modified",
+			opts: EqualOptions{},
+			args: args{
+				old: toJSON(a1),
+				new: toJSON(a1ConditionReasonUpdated),
+			},
+			want: true,
+		},
+		{
+			name: "Compare Reason Modified",
+			opts: EqualOptions{
+				ConditionCompareFields: []string{"type", "status", "reason"},
+			},
+			args: args{
+				old: toJSON(a1),
+				new: toJSON(a1ConditionReasonUpdated),
+			},
+			want: false,
+		},
+		{
+			name: "Compare All Time Modified",
+			opts: EqualOptions{
+				ConditionCompareFields: []string{"*"},
+			},
+			args: args{
+				old: toJSON(a1),
+				new: toJSON(a1ConditionTimeUpdated),
+			},
+			want: false,
+		},
+		{
+			name: "Compare All Ignore Times",
+			opts: EqualOptions{
+				ConditionCompareFields: []string{"*"},
+				ConditionIgnoreFields:  []string{"lastUpdateTime", "lastTransitionTime"},
+			},
+			args: args{
+				old: toJSON(a1),
+				new: toJSON(a1ConditionTimeUpdated),
+			},
+			want: true,
+		},
+		{
+			name: "Struct Compare All Time Modified",
+			opts: EqualOptions{
+				ConditionCompareFields: []string{"*"},
+			},
+			args: args{
+				old: d1,
+				new: d1ConditionTimeUpdated,
+			},
+			want: false,
+		},
+		{
+			name: "Ignore Paths",
+			opts: EqualOptions{
+				IgnorePaths: []string{"status.readyReplicas", "status.availableReplicas"},
+			},
+			args: args{
+				old: toJSON(a1),
+				new: toJSON(a1ReplicasUpdated),
+			},
+			want: true,
+		},
+		{
+			name: "Ignore Other Paths",
+			opts: EqualOptions{
+				IgnorePaths: []string{"status.readyReplicas"},
+			},
+			args: args{
+				old: toJSON(a1),
+				new: toJSON(a1ReplicasUpdated),
+			},
+			want: false,
+		},
+		{
+			name: "Ignore Condition",
+			opts: EqualOptions{
+				IgnorePaths: []string{"status.conditions[type=Progressing]"},
+			},
+			args: args{
+				old: toJSON(a1),
+				new: toJSON(a1ConditionStatusUpdated),
+			},
+			want: true,
+		},
+		{
+			name: "Ignore Condition Field",
+			opts: EqualOptions{
+				ConditionCompareFields: []string{"*"},
+				IgnorePaths:            []string{"status.conditions[*].lastUpdateTime", "status.conditions[*].lastTransitionTime"},
+			},
+			args: args{
+				old: d1,
+				new: d1ConditionTimeUpdated,
+			},
+			want: true,
+		},
+		{
+			name: "Condition Observed Generation Modified",
+			opts: EqualOptions{},
+			args: args{
+				old: conditionWithGeneration("True", 1),
+				new: conditionWithGeneration("True", 2),
+			},
+			want: false,
+		},
+		{
+			name: "Ignore Condition Observed Generation",
+			opts: EqualOptions{
+				IgnoreConditionObservedGeneration: true,
+			},
+			args: args{
+				old: conditionWithGeneration("True", 1),
+				new: conditionWithGeneration("True", 2),
+			},
+			want: true,
+		},
+		{
+			name: "Ignore Condition Observed Generation Status Modified",
+			opts: EqualOptions{
+				IgnoreConditionObservedGeneration: true,
+			},
+			args: args{
+				old: conditionWithGeneration("True", 1),
+				new: conditionWithGeneration("False", 2),
+			},
+			want: false,
+		},
+		{
+			name: "Nested Conditions Default",
+			opts: EqualOptions{},
+			args: args{
+				old: componentCondition("2021-05-08T19:03:45Z"),
+				new: componentCondition("2021-05-08T19:11:21Z"),
+			},
+			want: false,
+		},
+		{
+			name: "Nested Conditions Path",
+			opts: EqualOptions{
+				ConditionPaths: []string{"status.components[*].conditions"},
+			},
+			args: args{
+				old: componentCondition("2021-05-08T19:03:45Z"),
+				new: componentCondition("2021-05-08T19:11:21Z"),
+			},
+			want: true,
+		},
+		{
+			name: "Nested Conditions Detected",
+			opts: EqualOptions{
+				DetectConditions: true,
+			},
+			args: args{
+				old: componentCondition("2021-05-08T19:03:45Z"),
+				new: componentCondition("2021-05-08T19:11:21Z"),
+			},
+			want: true,
+		},
+		{
+			name: "Ignore Status",
+			opts: EqualOptions{
+				ConditionIgnoreFields: []string{"status"},
+			},
+			args: args{
+				old: d1,
+				new: d1ConditionStatusUpdated,
+			},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.StatusEqual(tt.args.old, tt.args.new); got != tt.want {
+				t.Errorf("StatusEqual() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func conditionWithGeneration(status string, generation int64) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": status, "observedGeneration": generation},
+			},
+		},
+	}}
+}
+
+func componentCondition(lastTransitionTime string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"components": []interface{}{
+				map[string]interface{}{
+					"name": "db",
+					"conditions": []interface{}{
+						map[string]interface{}{"type": "Ready", "status": "True", "lastTransitionTime": lastTransitionTime},
+					},
+				},
+			},
+		},
+	}}
+}
+
+func TestEqualOptions_EmptyEquivalence(t *testing.T) {
+	status := func(members ...interface{}) *unstructured.Unstructured {
+		m := map[string]interface{}{"replicas": int64(3)}
+		if len(members) > 0 {
+			m["members"] = members[0]
+		}
+		return &unstructured.Unstructured{Object: map[string]interface{}{"status": m}}
+	}
+	conditions := func(v interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{"status": map[string]interface{}{"conditions": v}}}
+	}
+	empty := []interface{}{}
+
+	tests := []struct {
+		name string
+		mode EmptyEquivalence
+		old  interface{}
+		new  interface{}
+		want bool
+	}{
+		{name: "Strict Null Missing", mode: EmptyStrict, old: status(nil), new: status(), want: false},
+		{name: "Strict Empty Missing", mode: EmptyStrict, old: status(empty), new: status(), want: false},
+		{name: "Strict Empty Null", mode: EmptyStrict, old: status(empty), new: status(nil), want: false},
+		{name: "Strict Empty Null Conditions", mode: EmptyStrict, old: conditions(empty), new: conditions(nil), want: false},
+		{name: "NullMissing Empty Null Conditions", mode: EmptyNullMissing, old: conditions(empty), new: conditions(nil), want: false},
+		{name: "All Empty Null Conditions", mode: EmptyAll, old: conditions(empty), new: conditions(nil), want: true},
+		{name: "NullMissing Null Missing", mode: EmptyNullMissing, old: status(nil), new: status(), want: true},
+		{name: "NullMissing Empty Missing", mode: EmptyNullMissing, old: status(empty), new: status(), want: false},
+		{name: "All Null Missing", mode: EmptyAll, old: status(nil), new: status(), want: true},
+		{name: "All Empty Missing", mode: EmptyAll, old: status(empty), new: status(), want: true},
+		{name: "All Empty Null", mode: EmptyAll, old: status(empty), new: status(nil), want: true},
+		{name: "All Empty Map Missing", mode: EmptyAll, old: status(map[string]interface{}{}), new: status(), want: true},
+		{name: "All Typed Unstructured", mode: EmptyAll, old: d1MissingCondition, new: toJSON(a1MissingCondition), want: true},
+		{name: "All Non Empty", mode: EmptyAll, old: status(empty), new: toJSON(a1), want: false},
+		// equal in any order, ie, transitive
+		{name: "All Nested Empty Map", mode: EmptyAll, old: status(map[string]interface{}{"y": empty}), new: status(map[string]interface{}{}), want: true},
+		{name: "All Empty Map Missing Reversed", mode: EmptyAll, old: status(map[string]interface{}{}), new: status(), want: true},
+		{name: "All Nested Empty Map Missing", mode: EmptyAll, old: status(map[string]interface{}{"y": empty}), new: status(), want: true},
+		{name: "All Nested Non Empty Map", mode: EmptyAll, old: status(map[string]interface{}{"y": []interface{}{"a"}}), new: status(), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := EqualOptions{EmptyEquivalence: tt.mode}
+			got, err := opts.StatusEqualE(tt.old, tt.new)
+			if err != nil {
+				t.Fatalf("StatusEqualE() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("StatusEqualE() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// pathSegments splits a field path into its segments, eg,
+// status.components[0].conditions[type=Ready] is split into
+// [status components [0] conditions [type=Ready]]
+func pathSegments(path string) ([]string, error) {
+	path = strings.TrimPrefix(path, "$")
+	path = strings.TrimPrefix(path, ".")
+
+	var segments []string
+	for len(path) > 0 {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("missing ] in path %q", path)
+			}
+			segments = append(segments, path[:end+1])
+			path = path[end+1:]
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			segments = append(segments, path[:end])
+			path = path[end:]
+		}
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("empty path")
+	}
+	return segments, nil
+}
+
+// pathMatcher matches field paths against a set of patterns.
+// A pattern matches a path if it matches the path or any of its parents.
+// "*" matches any field name and "[*]" matches any list element.
+type pathMatcher [][]string
+
+func newPathMatcher(patterns []string) (pathMatcher, error) {
+	m := make(pathMatcher, 0, len(patterns))
+	for _, p := range patterns {
+		segments, err := pathSegments(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path %q: %v", p, err)
+		}
+		m = append(m, segments)
+	}
+	return m, nil
+}
+
+func (m pathMatcher) Match(path string) bool {
+	return m.match(path, true)
+}
+
+// MatchExact is like Match but doesn't match the children of a matched path.
+func (m pathMatcher) MatchExact(path string) bool {
+	return m.match(path, false)
+}
+
+func (m pathMatcher) match(path string, prefix bool) bool {
+	if len(m) == 0 {
+		return false
+	}
+	segments, err := pathSegments(path)
+	if err != nil {
+		return false
+	}
+	for _, pattern := range m {
+		if (prefix || len(pattern) == len(segments)) && matchSegments(pattern, segments) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) > len(segments) {
+		return false
+	}
+	for i, p := range pattern {
+		s := segments[i]
+		isIndex := strings.HasPrefix(s, "[")
+		switch {
+		case p == "*" && !isIndex:
+		case p == "[*]" && isIndex:
+		case p != s:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestPathMatcher_Match(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		want     bool
+	}{
+		{
+			name:     "Exact",
+			patterns: []string{"status.lastHeartbeatTime"},
+			path:     "status.lastHeartbeatTime",
+			want:     true,
+		},
+		{
+			name:     "Nested",
+			patterns: []string{"status.stats"},
+			path:     "status.stats.reads",
+			want:     true,
+		},
+		{
+			name:     "Sibling",
+			patterns: []string{"status.stats"},
+			path:     "status.statsVersion",
+			want:     false,
+		},
+		{
+			name:     "Map Wildcard",
+			patterns: []string{"status.stats.*"},
+			path:     "status.stats.reads",
+			want:     true,
+		},
+		{
+			name:     "List Wildcard",
+			patterns: []string{"status.members[*].observedAt"},
+			path:     "status.members[3].observedAt",
+			want:     true,
+		},
+		{
+			name:     "List Wildcard Condition",
+			patterns: []string{"status.conditions[*].reason"},
+			path:     "status.conditions[type=Ready].reason",
+			want:     true,
+		},
+		{
+			name:     "Map Wildcard Does Not Match Index",
+			patterns: []string{"status.members.*"},
+			path:     "status.members[3]",
+			want:     false,
+		},
+		{
+			name:     "JSONPath Prefix",
+			patterns: []string{"$.status.observedAt"},
+			path:     "status.observedAt",
+			want:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := newPathMatcher(tt.patterns)
+			if err != nil {
+				t.Fatalf("newPathMatcher() error = %v", err)
+			}
+			if got := m.Match(tt.path); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPathMatcher_Invalid(t *testing.T) {
+	if _, err := newPathMatcher([]string{"status.members[0"}); err == nil {
+		t.Errorf("newPathMatcher() expected error for unbalanced bracket")
+	}
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// PolicyConfigAPIVersion is the apiVersion of the supported policy config format.
+	PolicyConfigAPIVersion = "status-equality-check/v1alpha1"
+	// PolicyConfigKind is the kind of the supported policy config format.
+	PolicyConfigKind = "PolicyConfig"
+	// DefaultPolicyConfigKey is the ConfigMap key read by WatchPolicyConfigMap if key is empty.
+	DefaultPolicyConfigKey = "policy.yaml"
+)
+
+// PolicyConfig declares the comparison policies of a Registry, eg,
+//
+//	apiVersion: status-equality-check/v1alpha1
+//	kind: PolicyConfig
+//	builtinPolicies: true
+//	policies:
+//	- selector:
+//	    group: db.example.com
+//	    kind: Database
+//	  ignorePaths:
+//	  - status.stats
+//	  listMapKeys:
+//	    status.members: [name]
+type PolicyConfig struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	// BuiltinPolicies registers the BuiltinPolicies before the policies of this config.
+	BuiltinPolicies bool `json:"builtinPolicies,omitempty"`
+	// Default is the policy of kinds without a registered policy.
+	Default PolicySpec `json:"default,omitempty"`
+	// Policies are the policies of the selected kinds.
+	Policies []Policy `json:"policies,omitempty"`
+}
+
+// Policy is the comparison policy of the kinds selected by Selector.
+type Policy struct {
+	Selector   PolicySelector `json:"selector"`
+	PolicySpec `json:",inline"`
+}
+
+// PolicySelector selects the kinds of a policy. Kind is required, Group is empty for core kinds.
+type PolicySelector struct {
+	Group string `json:"group,omitempty"`
+	Kind  string `json:"kind"`
+}
+
+// PolicySpec is the serialized form of EqualOptions.
+type PolicySpec struct {
+	IgnorePaths      []string             `json:"ignorePaths,omitempty"`
+	Conditions       ConditionRules       `json:"conditions,omitempty"`
+	ListMapKeys      map[string][]string  `json:"listMapKeys,omitempty"`
+	ListSets         []string             `json:"listSets,omitempty"`
+	EmptyEquivalence EmptyEquivalence     `json:"emptyEquivalence,omitempty"`
+	SemanticValues   bool                 `json:"semanticValues,omitempty"`
+	ValueTypes       map[string]ValueType `json:"valueTypes,omitempty"`
+}
+
+// ConditionRules are the condition fields of EqualOptions.
+type ConditionRules struct {
+	Paths                    []string `json:"paths,omitempty"`
+	Detect                   bool     `json:"detect,omitempty"`
+	CompareFields            []string `json:"compareFields,omitempty"`
+	IgnoreFields             []string `json:"ignoreFields,omitempty"`
+	IgnoreObservedGeneration bool     `json:"ignoreObservedGeneration,omitempty"`
+}
+
+// EqualOptions returns the EqualOptions declared by s.
+func (s PolicySpec) EqualOptions() EqualOptions {
+	empty, _ := parseEmptyEquivalence(string(s.EmptyEquivalence))
+	return EqualOptions{
+		ConditionCompareFields:            s.Conditions.CompareFields,
+		ConditionIgnoreFields:             s.Conditions.IgnoreFields,
+		IgnoreConditionObservedGeneration: s.Conditions.IgnoreObservedGeneration,
+		ConditionPaths:                    s.Conditions.Paths,
+		DetectConditions:                  s.Conditions.Detect,
+		ListMapKeys:                       s.ListMapKeys,
+		ListSets:                          s.ListSets,
+		IgnorePaths:                       s.IgnorePaths,
+		EmptyEquivalence:                  empty,
+		SemanticValues:                    s.SemanticValues,
+		ValueTypes:                        s.ValueTypes,
+	}
+}
+
+// PolicySpecFor returns the serialized form of opts.
+func PolicySpecFor(opts EqualOptions) PolicySpec {
+	return PolicySpec{
+		IgnorePaths: opts.IgnorePaths,
+		Conditions: ConditionRules{
+			Paths:                    opts.ConditionPaths,
+			Detect:                   opts.DetectConditions,
+			CompareFields:            opts.ConditionCompareFields,
+			IgnoreFields:             opts.ConditionIgnoreFields,
+			IgnoreObservedGeneration: opts.IgnoreConditionObservedGeneration,
+		},
+		ListMapKeys:      opts.ListMapKeys,
+		ListSets:         opts.ListSets,
+		EmptyEquivalence: opts.EmptyEquivalence,
+		SemanticValues:   opts.SemanticValues,
+		ValueTypes:       opts.ValueTypes,
+	}
+}
+
+// ParsePolicyConfig decodes and validates a YAML or JSON policy config.
+// Unknown fields are rejected, so that typos don't silently change the policies.
+func ParsePolicyConfig(data []byte) (*PolicyConfig, error) {
+	var c PolicyConfig
+	if err := yaml.UnmarshalStrict(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to decode policy config: %w", err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// LoadPolicyConfig reads, decodes and validates the policy config in filename.
+func LoadPolicyConfig(filename string) (*PolicyConfig, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	c, err := ParsePolicyConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return c, nil
+}
+
+// Validate returns every invalid field of c as a single error.
+func (c *PolicyConfig) Validate() error {
+	var errs field.ErrorList
+	if c.APIVersion != PolicyConfigAPIVersion {
+		errs = append(errs, field.NotSupported(field.NewPath("apiVersion"), c.APIVersion, []string{PolicyConfigAPIVersion}))
+	}
+	if c.Kind != PolicyConfigKind {
+		errs = append(errs, field.NotSupported(field.NewPath("kind"), c.Kind, []string{PolicyConfigKind}))
+	}
+	errs = append(errs, c.Default.validate(field.NewPath("default"))...)
+
+	seen := map[schema.GroupKind]bool{}
+	for i, p := range c.Policies {
+		fld := field.NewPath("policies").Index(i)
+		gk := p.Selector.GroupKind()
+		if gk.Kind == "" {
+			errs = append(errs, field.Required(fld.Child("selector", "kind"), ""))
+		} else if seen[gk] {
+			errs = append(errs, field.Duplicate(fld.Child("selector"), gk.String()))
+		}
+		seen[gk] = true
+		errs = append(errs, p.validate(fld)...)
+	}
+	return errs.ToAggregate()
+}
+
+func (s PolicySpec) validate(fld *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if _, err := newPathMatcher(s.IgnorePaths); err != nil {
+		errs = append(errs, field.Invalid(fld.Child("ignorePaths"), s.IgnorePaths, err.Error()))
+	}
+	if _, err := newPathMatcher(s.Conditions.Paths); err != nil {
+		errs = append(errs, field.Invalid(fld.Child("conditions", "paths"), s.Conditions.Paths, err.Error()))
+	}
+	if _, err := newListMapMatchers(s.ListMapKeys); err != nil {
+		errs = append(errs, field.Invalid(fld.Child("listMapKeys"), s.ListMapKeys, err.Error()))
+	}
+	if _, err := newPathMatcher(s.ListSets); err != nil {
+		errs = append(errs, field.Invalid(fld.Child("listSets"), s.ListSets, err.Error()))
+	}
+	if _, err := newValueTypeMatchers(s.ValueTypes); err != nil {
+		errs = append(errs, field.Invalid(fld.Child("valueTypes"), s.ValueTypes, err.Error()))
+	}
+	if _, err := parseEmptyEquivalence(string(s.EmptyEquivalence)); err != nil {
+		errs = append(errs, field.NotSupported(fld.Child("emptyEquivalence"), s.EmptyEquivalence, emptyEquivalenceNames))
+	}
+	return errs
+}
+
+// GroupKind returns the kind selected by s.
+func (s PolicySelector) GroupKind() schema.GroupKind {
+	return schema.GroupKind{Group: s.Group, Kind: s.Kind}
+}
+
+// Resolve returns the default policy and the policies by kind declared by c.
+func (c *PolicyConfig) Resolve() (EqualOptions, map[schema.GroupKind]EqualOptions) {
+	policies := map[schema.GroupKind]EqualOptions{}
+	if c.BuiltinPolicies {
+		policies = BuiltinPolicies()
+	}
+	for _, p := range c.Policies {
+		policies[p.Selector.GroupKind()] = p.EqualOptions()
+	}
+	return c.Default.EqualOptions(), policies
+}
+
+// Apply replaces every policy of r with the policies of c.
+func (c *PolicyConfig) Apply(r *Registry) {
+	fallback, policies := c.Resolve()
+	r.Replace(fallback, policies)
+}
+
+// WatchPolicyFile loads the policy config in filename into r and then checks the file
+// for changes every interval until ctx is done. Changed configs are applied to r;
+// invalid ones are logged and r keeps its previous policies.
+// Files mounted from a ConfigMap volume are reloaded when the ConfigMap is updated.
+// It returns an error if the initial config can't be loaded or interval is not positive.
+func WatchPolicyFile(ctx context.Context, filename string, interval time.Duration, r *Registry) error {
+	if interval <= 0 {
+		return fmt.Errorf("invalid policy reload interval %v", interval)
+	}
+	last, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	c, err := ParsePolicyConfig(last)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	c.Apply(r)
+
+	go wait.UntilWithContext(ctx, func(ctx context.Context) {
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			klog.Errorf("failed to read policy config %s: %v", filename, err)
+			return
+		}
+		if bytes.Equal(data, last) {
+			return
+		}
+		last = data
+		c, err := ParsePolicyConfig(data)
+		if err != nil {
+			klog.Errorf("ignoring invalid policy config %s: %v", filename, err)
+			return
+		}
+		c.Apply(r)
+		klog.Infof("reloaded policy config %s", filename)
+	}, interval)
+	return nil
+}
+
+// WatchPolicyConfigMap loads the policy config in the key of the namespace/name ConfigMap
+// into r and reloads it every time the ConfigMap is updated until ctx is done.
+// Key defaults to DefaultPolicyConfigKey. Invalid configs are logged and r keeps
+// its previous policies, as it does if the ConfigMap or key is deleted.
+// It returns after the ConfigMap has been read once, or an error if the ConfigMap
+// or key doesn't exist or the initial config is invalid.
+func WatchPolicyConfigMap(ctx context.Context, kc kubernetes.Interface, namespace, name, key string, r *Registry) error {
+	if key == "" {
+		key = DefaultPolicyConfigKey
+	}
+	selector := fields.OneTermEqualSelector("metadata.name", name).String()
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = selector
+			return kc.CoreV1().ConfigMaps(namespace).List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = selector
+			return kc.CoreV1().ConfigMaps(namespace).Watch(ctx, options)
+		},
+	}
+	informer := cache.NewSharedInformer(lw, &core.ConfigMap{}, 0)
+
+	load := func(obj interface{}) (*PolicyConfig, error) {
+		cm, ok := obj.(*core.ConfigMap)
+		if !ok {
+			return nil, fmt.Errorf("unexpected object %T", obj)
+		}
+		data, ok := cm.Data[key]
+		if !ok {
+			return nil, fmt.Errorf("ConfigMap %s/%s has no key %s", namespace, name, key)
+		}
+		c, err := ParsePolicyConfig([]byte(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy config in ConfigMap %s/%s: %w", namespace, name, err)
+		}
+		return c, nil
+	}
+	apply := func(obj interface{}) {
+		c, err := load(obj)
+		if err != nil {
+			klog.Errorf("ignoring policy config: %v", err)
+			return
+		}
+		c.Apply(r)
+		klog.Infof("loaded policy config from ConfigMap %s/%s", namespace, name)
+	}
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: apply,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			apply(newObj)
+		},
+	})
+
+	// the informer is stopped if the initial config can't be loaded
+	ctx, cancel := context.WithCancel(ctx)
+	go informer.Run(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		cancel()
+		return fmt.Errorf("failed to sync ConfigMap %s/%s", namespace, name)
+	}
+	// the event handlers may not have run yet, so the initial config is applied here
+	obj, exists, err := informer.GetStore().GetByKey(namespace + "/" + name)
+	if err == nil && !exists {
+		err = fmt.Errorf("ConfigMap %s/%s not found", namespace, name)
+	}
+	var c *PolicyConfig
+	if err == nil {
+		c, err = load(obj)
+	}
+	if err != nil {
+		cancel()
+		return err
+	}
+	c.Apply(r)
+	// the informer runs until the parent ctx is done
+	go func() {
+		<-ctx.Done()
+		cancel()
+	}()
+	return nil
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var (
+	policyConfig = `apiVersion: status-equality-check/v1alpha1
+kind: PolicyConfig
+builtinPolicies: true
+default:
+  emptyEquivalence: All
+policies:
+- selector:
+    group: db.example.com
+    kind: Database
+  ignorePaths:
+  - status.stats
+  conditions:
+    compareFields: [type, status, reason]
+  listMapKeys:
+    status.members: [name]
+  valueTypes:
+    status.lastBackupTime: Time
+`
+	policyConfigUpdated = `apiVersion: status-equality-check/v1alpha1
+kind: PolicyConfig
+policies:
+- selector:
+    group: db.example.com
+    kind: Database
+  ignorePaths:
+  - status.stats
+  - status.lastBackupTime
+`
+
+	databaseGK = schema.GroupKind{Group: "db.example.com", Kind: "Database"}
+)
+
+func TestParsePolicyConfig(t *testing.T) {
+	c, err := ParsePolicyConfig([]byte(policyConfig))
+	if err != nil {
+		t.Fatalf("ParsePolicyConfig() error = %v", err)
+	}
+	r := NewRegistry(nil)
+	c.Apply(r)
+
+	opts, ok := r.Lookup(databaseGK)
+	if !ok {
+		t.Fatalf("Lookup() found no policy for %v", databaseGK)
+	}
+	want := EqualOptions{
+		ConditionCompareFields: []string{"type", "status", "reason"},
+		ListMapKeys:            map[string][]string{"status.members": {"name"}},
+		IgnorePaths:            []string{"status.stats"},
+		ValueTypes:             map[string]ValueType{"status.lastBackupTime": TimeValue},
+	}
+	if !reflect.DeepEqual(opts, want) {
+		t.Errorf("Lookup() = %+v, want %+v", opts, want)
+	}
+	if _, ok := r.Lookup(schema.GroupKind{Group: "apps", Kind: "Deployment"}); !ok {
+		t.Errorf("Lookup() found no builtin policy for Deployment")
+	}
+	if opts, _ := r.Lookup(schema.GroupKind{Kind: "ConfigMap"}); opts.EmptyEquivalence != EmptyAll {
+		t.Errorf("Lookup() default EmptyEquivalence = %q, want %q", opts.EmptyEquivalence, EmptyAll)
+	}
+}
+
+func TestParsePolicyConfig_StrictEmptyEquivalence(t *testing.T) {
+	c, err := ParsePolicyConfig([]byte(`apiVersion: status-equality-check/v1alpha1
+kind: PolicyConfig
+default:
+  emptyEquivalence: Strict
+`))
+	if err != nil {
+		t.Fatalf("ParsePolicyConfig() error = %v", err)
+	}
+	if got := c.Default.EqualOptions().EmptyEquivalence; got != EmptyStrict {
+		t.Errorf("EqualOptions() EmptyEquivalence = %q, want %q", got, EmptyStrict)
+	}
+}
+
+func TestParsePolicyConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{
+			name:    "Unknown Field",
+			config:  policyConfig + "ignorePath: [status.stats]\n",
+			wantErr: "unknown field",
+		},
+		{
+			name:    "Unsupported Version",
+			config:  strings.Replace(policyConfig, "v1alpha1", "v2", 1),
+			wantErr: "apiVersion",
+		},
+		{
+			name: "Missing Kind",
+			config: `apiVersion: status-equality-check/v1alpha1
+kind: PolicyConfig
+policies:
+- selector:
+    group: apps
+`,
+			wantErr: "policies[0].selector.kind",
+		},
+		{
+			name: "Duplicate Selector",
+			config: `apiVersion: status-equality-check/v1alpha1
+kind: PolicyConfig
+policies:
+- selector: {group: apps, kind: Deployment}
+- selector: {group: apps, kind: Deployment}
+`,
+			wantErr: "policies[1].selector",
+		},
+		{
+			name: "Unknown Value Type",
+			config: `apiVersion: status-equality-check/v1alpha1
+kind: PolicyConfig
+default:
+  valueTypes:
+    status.capacity.*: Bytes
+`,
+			wantErr: "default.valueTypes",
+		},
+		{
+			name: "Unknown Empty Equivalence",
+			config: `apiVersion: status-equality-check/v1alpha1
+kind: PolicyConfig
+default:
+  emptyEquivalence: Some
+`,
+			wantErr: "default.emptyEquivalence",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePolicyConfig([]byte(tt.config))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParsePolicyConfig() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWatchPolicyFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := ioutil.WriteFile(filename, []byte(policyConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r := NewRegistry(nil)
+	if err := WatchPolicyFile(ctx, filename, 10*time.Millisecond, r); err != nil {
+		t.Fatalf("WatchPolicyFile() error = %v", err)
+	}
+	if _, ok := r.Lookup(schema.GroupKind{Group: "apps", Kind: "Deployment"}); !ok {
+		t.Fatalf("Lookup() found no builtin policy for Deployment")
+	}
+
+	if err := ioutil.WriteFile(filename, []byte("kind: [invalid"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if _, ok := r.Lookup(schema.GroupKind{Group: "apps", Kind: "Deployment"}); !ok {
+		t.Fatalf("Lookup() lost policies after invalid reload")
+	}
+
+	if err := ioutil.WriteFile(filename, []byte(policyConfigUpdated), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	waitForIgnorePaths(t, r, 2)
+}
+
+func TestWatchPolicyConfigMap(t *testing.T) {
+	cm := &core.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "status-policy"},
+		Data:       map[string]string{DefaultPolicyConfigKey: policyConfig},
+	}
+	kc := fake.NewSimpleClientset(cm)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r := NewRegistry(nil)
+	if err := WatchPolicyConfigMap(ctx, kc, "demo", "status-policy", "", r); err != nil {
+		t.Fatalf("WatchPolicyConfigMap() error = %v", err)
+	}
+	waitForIgnorePaths(t, r, 1)
+
+	cm = cm.DeepCopy()
+	cm.Data[DefaultPolicyConfigKey] = policyConfigUpdated
+	if _, err := kc.CoreV1().ConfigMaps("demo").Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitForIgnorePaths(t, r, 2)
+}
+
+func TestWatchPolicyFile_InvalidInterval(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := ioutil.WriteFile(filename, []byte(policyConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WatchPolicyFile(context.Background(), filename, 0, NewRegistry(nil)); err == nil {
+		t.Errorf("WatchPolicyFile() error = nil, want invalid interval")
+	}
+}
+
+func TestWatchPolicyConfigMap_Invalid(t *testing.T) {
+	configMap := func(key, data string) *core.ConfigMap {
+		return &core.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "status-policy"},
+			Data:       map[string]string{key: data},
+		}
+	}
+	tests := []struct {
+		name    string
+		objects []runtime.Object
+		wantErr string
+	}{
+		{
+			name:    "Missing ConfigMap",
+			wantErr: "not found",
+		},
+		{
+			name:    "Missing Key",
+			objects: []runtime.Object{configMap("config.yaml", policyConfig)},
+			wantErr: "has no key",
+		},
+		{
+			name:    "Invalid Config",
+			objects: []runtime.Object{configMap(DefaultPolicyConfigKey, "kind: [invalid")},
+			wantErr: "invalid policy config",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			err := WatchPolicyConfigMap(ctx, fake.NewSimpleClientset(tt.objects...), "demo", "status-policy", "", NewRegistry(nil))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("WatchPolicyConfigMap() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func waitForIgnorePaths(t *testing.T, r *Registry, n int) {
+	t.Helper()
+	err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		opts, _ := r.Lookup(databaseGK)
+		return len(opts.IgnorePaths) == n, nil
+	})
+	if err != nil {
+		opts, _ := r.Lookup(databaseGK)
+		t.Fatalf("Lookup() IgnorePaths = %v, want %d paths", opts.IgnorePaths, n)
+	}
+}
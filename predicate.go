@@ -0,0 +1,45 @@
+package main
+
+import (
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// StatusChangedPredicate passes update events only if the status of the object changed
+// according to opts. Create, delete and generic events are always passed.
+// Updates whose status can't be compared are passed, so that no change is lost.
+func StatusChangedPredicate(opts EqualOptions) predicate.Funcs {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			equal, ok := statusEqualEvent(opts, e)
+			return !ok || !equal
+		},
+	}
+}
+
+// StatusUnchangedPredicate passes update events only if the status of the object did not change
+// according to opts, ie, it drops updates that changed the status.
+// Create, delete and generic events are always passed.
+// Updates whose status can't be compared are passed, so that no change is lost.
+func StatusUnchangedPredicate(opts EqualOptions) predicate.Funcs {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			equal, ok := statusEqualEvent(opts, e)
+			return !ok || equal
+		},
+	}
+}
+
+func statusEqualEvent(opts EqualOptions, e event.UpdateEvent) (equal bool, ok bool) {
+	if e.ObjectOld == nil || e.ObjectNew == nil {
+		klog.Errorln("update event has no old or new object")
+		return false, false
+	}
+	equal, err := opts.StatusEqualE(e.ObjectOld, e.ObjectNew)
+	if err != nil {
+		klog.Errorln(err)
+		return false, false
+	}
+	return equal, true
+}
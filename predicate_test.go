@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestStatusPredicates(t *testing.T) {
+	tests := []struct {
+		name          string
+		old           client.Object
+		new           client.Object
+		wantChanged   bool
+		wantUnchanged bool
+	}{
+		{
+			name:          "Condition Time Modified",
+			old:           d1,
+			new:           d1ConditionTimeUpdated,
+			wantChanged:   false,
+			wantUnchanged: true,
+		},
+		{
+			name:          "Condition Status Modified",
+			old:           d1,
+			new:           d1ConditionStatusUpdated,
+			wantChanged:   true,
+			wantUnchanged: false,
+		},
+		{
+			name:          "Unstructured Condition Status Modified",
+			old:           toJSON(a1).(client.Object),
+			new:           toJSON(a1ConditionStatusUpdated).(client.Object),
+			wantChanged:   true,
+			wantUnchanged: false,
+		},
+		{
+			name:          "Missing Object",
+			old:           nil,
+			new:           d1,
+			wantChanged:   true,
+			wantUnchanged: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := event.UpdateEvent{ObjectOld: tt.old, ObjectNew: tt.new}
+			if got := StatusChangedPredicate(EqualOptions{}).Update(e); got != tt.wantChanged {
+				t.Errorf("StatusChangedPredicate.Update() = %v, want %v", got, tt.wantChanged)
+			}
+			if got := StatusUnchangedPredicate(EqualOptions{}).Update(e); got != tt.wantUnchanged {
+				t.Errorf("StatusUnchangedPredicate.Update() = %v, want %v", got, tt.wantUnchanged)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientsetscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/klog/v2"
+)
+
+// Registry maps GroupKinds to the EqualOptions used to compare their status.
+// Objects of kinds without a registered policy are compared with the default policy.
+// A Registry is safe for concurrent use.
+type Registry struct {
+	scheme *runtime.Scheme
+
+	mu       sync.RWMutex
+	policies map[schema.GroupKind]EqualOptions
+	fallback EqualOptions
+}
+
+// NewRegistry returns an empty Registry. The kind of typed objects without
+// TypeMeta is looked up in scheme, or in the client-go scheme if scheme is nil.
+func NewRegistry(scheme *runtime.Scheme) *Registry {
+	if scheme == nil {
+		scheme = clientsetscheme.Scheme
+	}
+	return &Registry{
+		scheme:   scheme,
+		policies: map[schema.GroupKind]EqualOptions{},
+	}
+}
+
+// NewDefaultRegistry returns a Registry with the BuiltinPolicies registered.
+func NewDefaultRegistry(scheme *runtime.Scheme) *Registry {
+	r := NewRegistry(scheme)
+	for gk, opts := range BuiltinPolicies() {
+		r.Register(gk, opts)
+	}
+	return r
+}
+
+// Register sets the policy used for objects of kind gk, replacing any previous policy.
+func (r *Registry) Register(gk schema.GroupKind, opts EqualOptions) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.policies[gk] = opts
+}
+
+// Unregister removes the policy of gk, so that its objects use the default policy.
+func (r *Registry) Unregister(gk schema.GroupKind) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.policies, gk)
+}
+
+// SetDefault sets the policy used for kinds without a registered policy.
+func (r *Registry) SetDefault(opts EqualOptions) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fallback = opts
+}
+
+// Replace atomically replaces the default policy and every registered policy of r.
+func (r *Registry) Replace(fallback EqualOptions, policies map[schema.GroupKind]EqualOptions) {
+	m := make(map[schema.GroupKind]EqualOptions, len(policies))
+	for gk, opts := range policies {
+		m[gk] = opts
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fallback = fallback
+	r.policies = m
+}
+
+// Lookup returns the policy of gk. If gk has no registered policy, it returns
+// the default policy and false.
+func (r *Registry) Lookup(gk schema.GroupKind) (EqualOptions, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if opts, ok := r.policies[gk]; ok {
+		return opts, true
+	}
+	return r.fallback, false
+}
+
+// OptionsFor returns the policy used to compare the status of old and new.
+// The kind is read from the new object, or from the old one if the kind of
+// the new object is unknown. The Scheme of the policy defaults to the scheme of r.
+func (r *Registry) OptionsFor(old, new interface{}) (EqualOptions, error) {
+	oldGK, err := r.groupKind(old)
+	if err != nil {
+		return EqualOptions{}, err
+	}
+	newGK, err := r.groupKind(new)
+	if err != nil {
+		return EqualOptions{}, err
+	}
+	gk := newGK
+	if gk.Empty() {
+		gk = oldGK
+	} else if !oldGK.Empty() && oldGK != newGK {
+		return EqualOptions{}, fmt.Errorf("%w: %v and %v", ErrGroupKindMismatch, oldGK, newGK)
+	}
+	opts, _ := r.Lookup(gk)
+	if opts.Scheme == nil {
+		opts.Scheme = r.scheme
+	}
+	return opts, nil
+}
+
+// StatusEqual reports whether old and new have semantically equal status
+// according to the policy of their kind.
+// Objects that can't be compared are reported as not equal.
+func (r *Registry) StatusEqual(old, new interface{}) bool {
+	equal, err := r.StatusEqualE(old, new)
+	if err != nil {
+		klog.Warningln(err)
+		return false
+	}
+	return equal
+}
+
+// StatusEqualE is like StatusEqual but returns an error if the objects can't be compared.
+func (r *Registry) StatusEqualE(old, new interface{}) (bool, error) {
+	opts, err := r.OptionsFor(old, new)
+	if err != nil {
+		return false, err
+	}
+	return opts.StatusEqualE(old, new)
+}
+
+// StatusCompare returns every field that differs between the status of old and new
+// according to the policy of their kind.
+func (r *Registry) StatusCompare(old, new interface{}) (*StatusDiff, error) {
+	opts, err := r.OptionsFor(old, new)
+	if err != nil {
+		return nil, err
+	}
+	return opts.StatusCompare(old, new)
+}
+
+// groupKind returns the kind of obj from its TypeMeta, or from the scheme for
+// typed objects with empty TypeMeta. Unknown kinds are returned as an empty GroupKind.
+func (r *Registry) groupKind(obj interface{}) (schema.GroupKind, error) {
+	switch o := obj.(type) {
+	case *unstructured.Unstructured:
+		if o == nil {
+			return schema.GroupKind{}, fmt.Errorf("%w: nil %v", ErrUnsupportedObject, reflect.TypeOf(obj))
+		}
+		return o.GroupVersionKind().GroupKind(), nil
+	case runtime.Object:
+		if gvk := o.GetObjectKind().GroupVersionKind(); !gvk.Empty() {
+			return gvk.GroupKind(), nil
+		}
+		gvks, _, err := r.scheme.ObjectKinds(o)
+		if err != nil || len(gvks) == 0 {
+			return schema.GroupKind{}, nil
+		}
+		return gvks[0].GroupKind(), nil
+	}
+	return schema.GroupKind{}, nil
+}
+
+// BuiltinPolicies returns the policies registered by NewDefaultRegistry for core, apps and batch kinds.
+// Condition timestamps are ignored even if ConditionCompareFields is widened, eg, to "*".
+// The returned map is a new copy that may be modified.
+func BuiltinPolicies() map[schema.GroupKind]EqualOptions {
+	workload := EqualOptions{
+		ConditionIgnoreFields: []string{"lastUpdateTime", "lastTransitionTime"},
+	}
+	return map[schema.GroupKind]EqualOptions{
+		{Group: "apps", Kind: "Deployment"}:  workload,
+		{Group: "apps", Kind: "ReplicaSet"}:  workload,
+		{Group: "apps", Kind: "StatefulSet"}: workload,
+		{Group: "apps", Kind: "DaemonSet"}:   workload,
+		{Group: "", Kind: "Pod"}: {
+			ConditionIgnoreFields: []string{"lastProbeTime", "lastTransitionTime"},
+			ListMapKeys: map[string][]string{
+				"status.podIPs":                     {"ip"},
+				"status.initContainerStatuses":      {"name"},
+				"status.containerStatuses":          {"name"},
+				"status.ephemeralContainerStatuses": {"name"},
+			},
+			ValueTypes: map[string]ValueType{
+				"status.startTime": TimeValue,
+			},
+		},
+		{Group: "", Kind: "Node"}: {
+			ConditionIgnoreFields: []string{"lastHeartbeatTime", "lastTransitionTime"},
+			ListMapKeys: map[string][]string{
+				"status.addresses": {"type", "address"},
+			},
+			ValueTypes: map[string]ValueType{
+				"status.capacity.*":    QuantityValue,
+				"status.allocatable.*": QuantityValue,
+			},
+		},
+		{Group: "", Kind: "Service"}: {
+			ListMapKeys: map[string][]string{
+				"status.loadBalancer.ingress": {"ip", "hostname"},
+			},
+		},
+		{Group: "", Kind: "PersistentVolumeClaim"}: {
+			ValueTypes: map[string]ValueType{
+				"status.capacity.*": QuantityValue,
+			},
+		},
+		{Group: "batch", Kind: "Job"}: {
+			ConditionIgnoreFields: []string{"lastProbeTime", "lastTransitionTime"},
+			ValueTypes: map[string]ValueType{
+				"status.startTime":      TimeValue,
+				"status.completionTime": TimeValue,
+			},
+		},
+		{Group: "batch", Kind: "CronJob"}: {
+			ListMapKeys: map[string][]string{
+				"status.active": {"uid"},
+			},
+			ValueTypes: map[string]ValueType{
+				"status.lastScheduleTime": TimeValue,
+			},
+		},
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestRegistry_StatusEqualE(t *testing.T) {
+	database := func(reads int64) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "db.example.com/v1",
+			"kind":       "Database",
+			"status": map[string]interface{}{
+				"phase": "Ready",
+				"stats": map[string]interface{}{"reads": reads},
+			},
+		}}
+	}
+
+	r := NewDefaultRegistry(nil)
+	r.Register(schema.GroupKind{Group: "db.example.com", Kind: "Database"}, EqualOptions{
+		IgnorePaths: []string{"status.stats"},
+	})
+
+	tests := []struct {
+		name    string
+		old     interface{}
+		new     interface{}
+		want    bool
+		wantErr error
+	}{
+		{
+			name: "Typed Pod Without TypeMeta",
+			old:  pod("app", "sidecar"),
+			new:  pod("sidecar", "app"),
+			want: true,
+		},
+		{
+			name: "Unstructured Pod Without Kind",
+			old:  toUnstructured(pod("app", "sidecar")),
+			new:  toUnstructured(pod("sidecar", "app")),
+			want: false,
+		},
+		{
+			name: "Deployment Reason Updated",
+			old:  toJSON(a1),
+			new:  toJSON(a1ConditionReasonUpdated),
+			want: true,
+		},
+		{
+			name: "Deployment Status Updated",
+			old:  toJSON(a1),
+			new:  toJSON(a1ConditionStatusUpdated),
+			want: false,
+		},
+		{
+			name: "Registered Kind",
+			old:  database(10),
+			new:  database(20),
+			want: true,
+		},
+		{
+			name:    "Kind Mismatch",
+			old:     toJSON(a1),
+			new:     database(10),
+			wantErr: ErrGroupKindMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.StatusEqualE(tt.old, tt.new)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("StatusEqualE() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("StatusEqualE() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistry_Lookup(t *testing.T) {
+	r := NewRegistry(nil)
+	fallback := EqualOptions{IgnorePaths: []string{"status.observedAt"}}
+	r.SetDefault(fallback)
+
+	gk := schema.GroupKind{Group: "apps", Kind: "Deployment"}
+	if _, ok := r.Lookup(gk); ok {
+		t.Errorf("Lookup() found policy in empty registry")
+	}
+	r.Register(gk, EqualOptions{DetectConditions: true})
+	if opts, ok := r.Lookup(gk); !ok || !opts.DetectConditions {
+		t.Errorf("Lookup() = %v, %v, want registered policy", opts, ok)
+	}
+	r.Unregister(gk)
+	if opts, ok := r.Lookup(gk); ok || len(opts.IgnorePaths) != 1 {
+		t.Errorf("Lookup() = %v, %v, want default policy", opts, ok)
+	}
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"gomodules.xyz/pointer"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	statusTypeName  = "status"
+	deducedTypeName = "__untyped_deduced_"
+	atomicTypeName  = "__untyped_atomic_"
+)
+
+// SchemaComparator compares the status of custom resources using the openAPIV3Schema
+// of their CustomResourceDefinition. The schema decides how lists are compared
+// (x-kubernetes-list-type atomic, set or map), which fields are times or int-or-strings
+// and the defaults of missing fields. Statuses that don't match the schema can't be compared.
+type SchemaComparator struct {
+	parser *typed.Parser
+	// Options are the comparison options. List semantics and value types declared
+	// here take precedence over the ones from the schema.
+	Options EqualOptions
+
+	listMapKeys map[string][]string
+	listSets    []string
+	valueTypes  map[string]ValueType
+}
+
+// LoadSchemaComparator reads a CustomResourceDefinition from a yaml or json file and
+// returns a comparator for the given version. If version is empty, the storage version is used.
+func LoadSchemaComparator(filename, version string, opts EqualOptions) (*SchemaComparator, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	data, err = yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	obj, _, err := unstructured.UnstructuredJSONScheme.Decode(data, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	crd, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("%s does not contain a single CustomResourceDefinition", filename)
+	}
+	return SchemaComparatorFromCRD(crd, version, opts)
+}
+
+// SchemaComparatorFromCRD returns a comparator for the given version of a CustomResourceDefinition.
+// Both apiextensions.k8s.io/v1 and v1beta1 are supported. If version is empty, the storage version is used.
+func SchemaComparatorFromCRD(crd *unstructured.Unstructured, version string, opts EqualOptions) (*SchemaComparator, error) {
+	if crd.GetKind() != "CustomResourceDefinition" {
+		return nil, fmt.Errorf("%s %s is not a CustomResourceDefinition", crd.GetKind(), crd.GetName())
+	}
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	// an explicit version must be served by the CRD, ie, listed in spec.versions or spec.version
+	found := version == ""
+	if v, _, _ := unstructured.NestedString(crd.Object, "spec", "version"); v != "" && v == version {
+		found = true
+	}
+	for _, item := range versions {
+		v, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := v["name"].(string)
+		storage, _ := v["storage"].(bool)
+		if name != version && !(version == "" && storage) && !(version == "" && len(versions) == 1) {
+			continue
+		}
+		found = true
+		if s, ok, _ := unstructured.NestedMap(v, "schema", "openAPIV3Schema"); ok {
+			return NewSchemaComparator(s, opts)
+		}
+		break
+	}
+	// v1beta1 CRDs may have a single schema for all versions
+	if s, ok, _ := unstructured.NestedMap(crd.Object, "spec", "validation", "openAPIV3Schema"); ok && found {
+		return NewSchemaComparator(s, opts)
+	}
+	return nil, fmt.Errorf("no openAPIV3Schema found for version %q of %s", version, crd.GetName())
+}
+
+// NewSchemaComparator returns a comparator for the objects described by openAPIV3Schema.
+func NewSchemaComparator(openAPIV3Schema map[string]interface{}, opts EqualOptions) (*SchemaComparator, error) {
+	status, ok, err := unstructured.NestedMap(openAPIV3Schema, "properties", "status")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("schema has no status property")
+	}
+
+	c := &SchemaComparator{
+		Options:     opts,
+		listMapKeys: map[string][]string{},
+		valueTypes:  map[string]ValueType{},
+	}
+	atom, err := c.convert("status", status)
+	if err != nil {
+		return nil, err
+	}
+	c.parser = &typed.Parser{Schema: schema.Schema{Types: append(untypedTypeDefs(), schema.TypeDef{
+		Name: statusTypeName,
+		Atom: atom,
+	})}}
+	return c, nil
+}
+
+// EqualOptions returns Options completed with the list semantics and value types of the schema.
+func (c *SchemaComparator) EqualOptions() EqualOptions {
+	opts := c.Options
+	opts.ListMapKeys = map[string][]string{}
+	for k, v := range c.listMapKeys {
+		opts.ListMapKeys[k] = v
+	}
+	for k, v := range c.Options.ListMapKeys {
+		opts.ListMapKeys[k] = v
+	}
+	opts.ValueTypes = map[string]ValueType{}
+	for k, v := range c.valueTypes {
+		opts.ValueTypes[k] = v
+	}
+	for k, v := range c.Options.ValueTypes {
+		opts.ValueTypes[k] = v
+	}
+	opts.ListSets = append(append([]string{}, c.listSets...), c.Options.ListSets...)
+	return opts
+}
+
+func (c *SchemaComparator) StatusEqual(old, new interface{}) bool {
+	equal, err := c.StatusEqualE(old, new)
+	if err != nil {
+		klog.Warningln(err)
+		return false
+	}
+	return equal
+}
+
+func (c *SchemaComparator) StatusEqualE(old, new interface{}) (bool, error) {
+	diff, err := c.StatusCompare(old, new)
+	if err != nil {
+		return false, err
+	}
+	return diff.Equal(), nil
+}
+
+// StatusCompare returns every field that differs between the status of old and new,
+// after validating both statuses against the schema and applying its defaults.
+func (c *SchemaComparator) StatusCompare(old, new interface{}) (*StatusDiff, error) {
+	oldObj, err := c.prepare(old)
+	if err != nil {
+		return nil, err
+	}
+	newObj, err := c.prepare(new)
+	if err != nil {
+		return nil, err
+	}
+	return c.EqualOptions().StatusCompare(oldObj, newObj)
+}
+
+// prepare returns an unstructured object holding the defaulted status of o.
+func (c *SchemaComparator) prepare(o interface{}) (*unstructured.Unstructured, error) {
+	status, exists, err := extractStatusFromObject(o)
+	if err != nil {
+		return nil, err
+	}
+	out := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	if !exists {
+		return out, nil
+	}
+	m, err := statusToMap(status)
+	if err != nil {
+		return nil, err
+	}
+	typeRef := schema.TypeRef{NamedType: pointer.StringP(statusTypeName)}
+	defaulted := c.applyDefaults(copyJSON(m), typeRef)
+	if _, err := c.parser.Type(statusTypeName).FromUnstructured(defaulted); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStatusDecode, err)
+	}
+	out.Object["status"] = defaulted
+	return out, nil
+}
+
+// applyDefaults sets the schema defaults of missing fields in v. v is modified in place.
+func (c *SchemaComparator) applyDefaults(v interface{}, ref schema.TypeRef) interface{} {
+	atom, ok := c.parser.Schema.Resolve(ref)
+	if !ok {
+		return v
+	}
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if atom.Map == nil {
+			return v
+		}
+		for _, f := range atom.Map.Fields {
+			if _, ok := t[f.Name]; !ok && f.Default != nil {
+				t[f.Name] = copyJSON(f.Default)
+			}
+		}
+		for k, item := range t {
+			if f, ok := atom.Map.FindField(k); ok {
+				t[k] = c.applyDefaults(item, f.Type)
+			} else {
+				t[k] = c.applyDefaults(item, atom.Map.ElementType)
+			}
+		}
+	case []interface{}:
+		if atom.List == nil {
+			return v
+		}
+		for i, item := range t {
+			t[i] = c.applyDefaults(item, atom.List.ElementType)
+		}
+	}
+	return v
+}
+
+// convert converts a json schema into a structured-merge-diff atom and records
+// the list semantics and value types of the fields under path.
+func (c *SchemaComparator) convert(path string, s map[string]interface{}) (schema.Atom, error) {
+	if b, _ := s["x-kubernetes-int-or-string"].(bool); b {
+		c.valueTypes[path] = IntOrStringValue
+		return deducedAtom(), nil
+	}
+
+	typ, _ := s["type"].(string)
+	preserveUnknown, _ := s["x-kubernetes-preserve-unknown-fields"].(bool)
+	switch typ {
+	case "object":
+		m := &schema.Map{}
+		props, _ := s["properties"].(map[string]interface{})
+		names := make([]string, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop, ok := props[name].(map[string]interface{})
+			if !ok {
+				return schema.Atom{}, fmt.Errorf("invalid schema for %s.%s", path, name)
+			}
+			atom, err := c.convert(path+"."+name, prop)
+			if err != nil {
+				return schema.Atom{}, err
+			}
+			m.Fields = append(m.Fields, schema.StructField{
+				Name:    name,
+				Type:    schema.TypeRef{Inlined: atom},
+				Default: prop["default"],
+			})
+		}
+		if additional, ok := s["additionalProperties"].(map[string]interface{}); ok {
+			atom, err := c.convert(path+".*", additional)
+			if err != nil {
+				return schema.Atom{}, err
+			}
+			m.ElementType = schema.TypeRef{Inlined: atom}
+		} else if preserveUnknown || len(props) == 0 {
+			m.ElementType = schema.TypeRef{NamedType: pointer.StringP(deducedTypeName)}
+		}
+		if mapType, _ := s["x-kubernetes-map-type"].(string); mapType == "atomic" {
+			m.ElementRelationship = schema.Atomic
+		}
+		return schema.Atom{Map: m}, nil
+	case "array":
+		l := &schema.List{ElementRelationship: schema.Atomic}
+		if items, ok := s["items"].(map[string]interface{}); ok {
+			atom, err := c.convert(path+"[*]", items)
+			if err != nil {
+				return schema.Atom{}, err
+			}
+			l.ElementType = schema.TypeRef{Inlined: atom}
+		} else {
+			l.ElementType = schema.TypeRef{NamedType: pointer.StringP(atomicTypeName)}
+		}
+		switch listType, _ := s["x-kubernetes-list-type"].(string); listType {
+		case "map":
+			raw, _ := s["x-kubernetes-list-map-keys"].([]interface{})
+			for _, k := range raw {
+				if key, ok := k.(string); ok {
+					l.Keys = append(l.Keys, key)
+				}
+			}
+			if len(l.Keys) == 0 {
+				return schema.Atom{}, fmt.Errorf("list map %s has no x-kubernetes-list-map-keys", path)
+			}
+			l.ElementRelationship = schema.Associative
+			c.listMapKeys[path] = l.Keys
+		case "set":
+			l.ElementRelationship = schema.Associative
+			c.listSets = append(c.listSets, path)
+		}
+		return schema.Atom{List: l}, nil
+	case "string":
+		if format, _ := s["format"].(string); format == "date-time" {
+			c.valueTypes[path] = TimeValue
+		}
+		scalar := schema.String
+		return schema.Atom{Scalar: &scalar}, nil
+	case "integer", "number":
+		scalar := schema.Numeric
+		return schema.Atom{Scalar: &scalar}, nil
+	case "boolean":
+		scalar := schema.Boolean
+		return schema.Atom{Scalar: &scalar}, nil
+	case "":
+		return deducedAtom(), nil
+	}
+	return schema.Atom{}, fmt.Errorf("unknown type %q for %s", typ, path)
+}
+
+// untypedTypeDefs returns the types used for fields without a schema, as defined by structured-merge-diff.
+func untypedTypeDefs() []schema.TypeDef {
+	untyped := schema.Scalar("untyped")
+	return []schema.TypeDef{
+		{
+			Name: atomicTypeName,
+			Atom: schema.Atom{
+				Scalar: &untyped,
+				List: &schema.List{
+					ElementType:         schema.TypeRef{NamedType: pointer.StringP(atomicTypeName)},
+					ElementRelationship: schema.Atomic,
+				},
+				Map: &schema.Map{
+					ElementType:         schema.TypeRef{NamedType: pointer.StringP(atomicTypeName)},
+					ElementRelationship: schema.Atomic,
+				},
+			},
+		},
+		{
+			Name: deducedTypeName,
+			Atom: deducedAtom(),
+		},
+	}
+}
+
+func deducedAtom() schema.Atom {
+	untyped := schema.Scalar("untyped")
+	return schema.Atom{
+		Scalar: &untyped,
+		List: &schema.List{
+			ElementType:         schema.TypeRef{NamedType: pointer.StringP(atomicTypeName)},
+			ElementRelationship: schema.Atomic,
+		},
+		Map: &schema.Map{
+			ElementType: schema.TypeRef{NamedType: pointer.StringP(deducedTypeName)},
+		},
+	}
+}
+
+// copyJSON deep copies the maps and lists of an unstructured value.
+func copyJSON(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, item := range t {
+			out[k] = copyJSON(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, item := range t {
+			out[i] = copyJSON(item)
+		}
+		return out
+	}
+	return v
+}
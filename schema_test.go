@@ -0,0 +1,185 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var (
+	db1 = `apiVersion: db.example.com/v1
+kind: Database
+metadata:
+  name: db
+  namespace: demo
+status:
+  phase: Pending
+  port: 5432
+  lastBackupTime: '2021-05-08T19:03:45Z'
+  addresses:
+  - 10.0.0.1
+  - 10.0.0.2
+  members:
+  - name: db-0
+    role: primary
+    ready: true
+  - name: db-1
+    role: replica
+    ready: true
+  stats:
+    reads: 10
+  conditions:
+  - type: Ready
+    status: 'True'
+    lastTransitionTime: '2021-05-08T19:03:45Z'
+`
+	db1Reordered = `apiVersion: db.example.com/v1
+kind: Database
+metadata:
+  name: db
+  namespace: demo
+status:
+  port: '5432'
+  lastBackupTime: '2021-05-08T19:03:45.000Z'
+  addresses:
+  - 10.0.0.2
+  - 10.0.0.1
+  members:
+  - name: db-1
+    ready: true
+  - name: db-0
+    role: primary
+    ready: true
+  stats:
+    reads: 10
+  conditions:
+  - type: Ready
+    status: 'True'
+    lastTransitionTime: '2021-05-08T19:11:21Z'
+`
+	db1MemberNotReady = `apiVersion: db.example.com/v1
+kind: Database
+metadata:
+  name: db
+  namespace: demo
+status:
+  phase: Pending
+  port: 5432
+  lastBackupTime: '2021-05-08T19:03:45Z'
+  addresses:
+  - 10.0.0.1
+  - 10.0.0.2
+  members:
+  - name: db-1
+    role: replica
+    ready: false
+  - name: db-0
+    role: primary
+    ready: true
+  stats:
+    reads: 10
+  conditions:
+  - type: Ready
+    status: 'True'
+    lastTransitionTime: '2021-05-08T19:03:45Z'
+`
+	db1Invalid = `apiVersion: db.example.com/v1
+kind: Database
+metadata:
+  name: db
+  namespace: demo
+status:
+  phase: 3
+`
+)
+
+func TestSchemaComparator_StatusCompare(t *testing.T) {
+	c, err := LoadSchemaComparator("testdata/database-crd.yaml", "", EqualOptions{})
+	if err != nil {
+		t.Fatalf("LoadSchemaComparator() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		old     interface{}
+		new     interface{}
+		paths   []string
+		wantErr error
+	}{
+		{
+			name: "Reordered And Defaulted",
+			old:  toJSON(db1),
+			new:  toJSON(db1Reordered),
+		},
+		{
+			name:  "Member Not Ready",
+			old:   toJSON(db1),
+			new:   toJSON(db1MemberNotReady),
+			paths: []string{"status.members[name=db-1].ready"},
+		},
+		{
+			name:    "Invalid",
+			old:     toJSON(db1),
+			new:     toJSON(db1Invalid),
+			wantErr: ErrStatusDecode,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.StatusCompare(tt.old, tt.new)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("StatusCompare() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(got.Fields) != len(tt.paths) {
+				t.Fatalf("StatusCompare() = %v, want paths %v", got, tt.paths)
+			}
+			for i, f := range got.Fields {
+				if f.Path != tt.paths[i] {
+					t.Errorf("StatusCompare() path = %v, want %v", f.Path, tt.paths[i])
+				}
+			}
+		})
+	}
+
+	// without the schema, the same statuses differ
+	if StatusEqual(toJSON(db1), toJSON(db1Reordered)) {
+		t.Errorf("StatusEqual() = true, want false")
+	}
+}
+
+func TestLoadSchemaComparator_UnknownVersion(t *testing.T) {
+	if _, err := LoadSchemaComparator("testdata/database-crd.yaml", "v2", EqualOptions{}); err == nil {
+		t.Errorf("LoadSchemaComparator() expected error for unknown version")
+	}
+}
+
+func TestSchemaComparatorFromCRD_V1beta1UnknownVersion(t *testing.T) {
+	crd := toJSON(`apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: databases.db.example.com
+spec:
+  group: db.example.com
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: true
+  validation:
+    openAPIV3Schema:
+      type: object
+      properties:
+        status:
+          type: object
+`).(*unstructured.Unstructured)
+	if _, err := SchemaComparatorFromCRD(crd, "v1alpha1", EqualOptions{}); err != nil {
+		t.Fatalf("SchemaComparatorFromCRD() error = %v", err)
+	}
+	if _, err := SchemaComparatorFromCRD(crd, "v2", EqualOptions{}); err == nil || !strings.Contains(err.Error(), "no openAPIV3Schema found") {
+		t.Errorf("SchemaComparatorFromCRD() error = %v, want no openAPIV3Schema found", err)
+	}
+}
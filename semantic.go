@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+	"unicode"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// ValueType declares how an unstructured status field is compared.
+type ValueType string
+
+const (
+	// QuantityValue fields are compared as resource.Quantity, eg, "1000m" equals "1".
+	QuantityValue ValueType = "Quantity"
+	// TimeValue fields are compared as metav1.Time, ie, with second precision.
+	TimeValue ValueType = "Time"
+	// MicroTimeValue fields are compared as metav1.MicroTime, ie, with microsecond precision.
+	MicroTimeValue ValueType = "MicroTime"
+	// IntOrStringValue fields are compared as intstr.IntOrString, eg, 80 equals "80".
+	IntOrStringValue ValueType = "IntOrString"
+)
+
+type valueTypeMatcher struct {
+	paths pathMatcher
+	typ   ValueType
+}
+
+func newValueTypeMatchers(valueTypes map[string]ValueType) ([]valueTypeMatcher, error) {
+	out := make([]valueTypeMatcher, 0, len(valueTypes))
+	for _, path := range unionKeys(valueTypes) {
+		typ := valueTypes[path]
+		switch typ {
+		case QuantityValue, TimeValue, MicroTimeValue, IntOrStringValue:
+		default:
+			return nil, fmt.Errorf("unknown value type %q for path %q", typ, path)
+		}
+		m, err := newPathMatcher([]string{path})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, valueTypeMatcher{paths: m, typ: typ})
+	}
+	return out, nil
+}
+
+// valuesEqual compares two leaf values. Typed Kubernetes values are always compared
+// semantically, unstructured values only if declared by ValueTypes or SemanticValues is set.
+func (d *differ) valuesEqual(path string, old, nu interface{}) bool {
+	if semanticEqual(old, nu) {
+		return true
+	}
+	for _, m := range d.valueTypes {
+		if m.paths.MatchExact(path) {
+			return unstructuredEqual(m.typ, old, nu)
+		}
+	}
+	if d.opts.SemanticValues {
+		for _, typ := range []ValueType{TimeValue, QuantityValue, IntOrStringValue} {
+			if typ == QuantityValue && !quantityLike(old, nu) {
+				continue
+			}
+			if unstructuredEqual(typ, old, nu) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// quantityLike reports whether old and nu are guessed to be quantities, ie, one of them
+// has a unit suffix or one is a number and the other a string. Plain numeric strings,
+// eg, versions like "1.20" and "1.2", are not quantities.
+func quantityLike(old, nu interface{}) bool {
+	if hasUnitSuffix(old) || hasUnitSuffix(nu) {
+		return true
+	}
+	_, oldString := old.(string)
+	_, nuString := nu.(string)
+	return (isNumber(old) && nuString) || (oldString && isNumber(nu))
+}
+
+func hasUnitSuffix(v interface{}) bool {
+	s, ok := v.(string)
+	if !ok || s == "" || !unicode.IsLetter(rune(s[len(s)-1])) {
+		return false
+	}
+	_, err := resource.ParseQuantity(s)
+	return err == nil
+}
+
+func isNumber(v interface{}) bool {
+	switch v.(type) {
+	case int64, float64:
+		return true
+	}
+	return false
+}
+
+func semanticEqual(old, nu interface{}) bool {
+	switch o := old.(type) {
+	case resource.Quantity:
+		n, ok := nu.(resource.Quantity)
+		return ok && o.Cmp(n) == 0
+	case metav1.Time:
+		n, ok := nu.(metav1.Time)
+		return ok && o.Truncate(time.Second).Equal(n.Truncate(time.Second))
+	case metav1.MicroTime:
+		n, ok := nu.(metav1.MicroTime)
+		return ok && o.Truncate(time.Microsecond).Equal(n.Truncate(time.Microsecond))
+	case intstr.IntOrString:
+		n, ok := nu.(intstr.IntOrString)
+		return ok && o.String() == n.String()
+	}
+	return reflect.DeepEqual(old, nu)
+}
+
+func unstructuredEqual(typ ValueType, old, nu interface{}) bool {
+	switch typ {
+	case QuantityValue:
+		o, err := parseQuantity(old)
+		if err != nil {
+			return false
+		}
+		n, err := parseQuantity(nu)
+		return err == nil && o.Cmp(n) == 0
+	case TimeValue, MicroTimeValue:
+		o, ok := parseTime(old)
+		if !ok {
+			return false
+		}
+		n, ok := parseTime(nu)
+		if !ok {
+			return false
+		}
+		precision := time.Second
+		if typ == MicroTimeValue {
+			precision = time.Microsecond
+		}
+		return o.Truncate(precision).Equal(n.Truncate(precision))
+	case IntOrStringValue:
+		o, ok := intOrString(old)
+		if !ok {
+			return false
+		}
+		n, ok := intOrString(nu)
+		return ok && o == n
+	}
+	return false
+}
+
+func parseQuantity(v interface{}) (resource.Quantity, error) {
+	switch q := v.(type) {
+	case string:
+		return resource.ParseQuantity(q)
+	case int64:
+		return *resource.NewQuantity(q, resource.DecimalSI), nil
+	case float64:
+		return resource.ParseQuantity(strconv.FormatFloat(q, 'f', -1, 64))
+	}
+	return resource.Quantity{}, fmt.Errorf("%v is not a quantity", v)
+}
+
+func parseTime(v interface{}) (time.Time, bool) {
+	s, ok := v.(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	return t, err == nil
+}
+
+// intOrString returns the string form of an int or string value.
+func intOrString(v interface{}) (string, bool) {
+	switch i := v.(type) {
+	case string:
+		return i, true
+	case int64:
+		return strconv.FormatInt(i, 10), true
+	case float64:
+		if i != float64(int64(i)) {
+			return "", false
+		}
+		return strconv.FormatInt(int64(i), 10), true
+	}
+	return "", false
+}
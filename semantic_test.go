@@ -0,0 +1,154 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestStatusEqual_SemanticValues(t *testing.T) {
+	node := func(cpu string, heartbeat time.Time) *core.Node {
+		return &core.Node{
+			Status: core.NodeStatus{
+				Capacity: core.ResourceList{core.ResourceCPU: resource.MustParse(cpu)},
+				Conditions: []core.NodeCondition{
+					{Type: core.NodeReady, Status: core.ConditionTrue, LastHeartbeatTime: metav1.NewTime(heartbeat)},
+				},
+			},
+		}
+	}
+	now := time.Date(2021, 5, 8, 19, 3, 45, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		opts EqualOptions
+		old  interface{}
+		new  interface{}
+		want bool
+	}{
+		{
+			name: "Typed Quantity",
+			old:  node("1000m", now),
+			new:  node("1", now),
+			want: true,
+		},
+		{
+			name: "Typed Time Precision",
+			opts: EqualOptions{ConditionCompareFields: []string{"*"}},
+			old:  node("1", now),
+			new:  node("1", now.Add(300*time.Millisecond)),
+			want: true,
+		},
+		{
+			name: "Typed Time Modified",
+			opts: EqualOptions{ConditionCompareFields: []string{"*"}},
+			old:  node("1", now),
+			new:  node("1", now.Add(time.Minute)),
+			want: false,
+		},
+		{
+			name: "Unstructured Quantity",
+			old:  status("cpu", "1000m"),
+			new:  status("cpu", "1"),
+			want: false,
+		},
+		{
+			name: "Unstructured Quantity Heuristic",
+			opts: EqualOptions{SemanticValues: true},
+			old:  status("cpu", "1000m"),
+			new:  status("cpu", "1"),
+			want: true,
+		},
+		{
+			name: "Unstructured Quantity Hint",
+			opts: EqualOptions{ValueTypes: map[string]ValueType{"status.*": QuantityValue}},
+			old:  status("cpu", "1000m"),
+			new:  status("cpu", "1"),
+			want: true,
+		},
+		{
+			name: "Unstructured Time Heuristic",
+			opts: EqualOptions{SemanticValues: true},
+			old:  status("observedAt", "2021-05-08T19:03:45Z"),
+			new:  status("observedAt", "2021-05-08T21:03:45.000000+02:00"),
+			want: true,
+		},
+		{
+			name: "Unstructured Time Hint Precision",
+			opts: EqualOptions{ValueTypes: map[string]ValueType{"status.observedAt": TimeValue}},
+			old:  status("observedAt", "2021-05-08T19:03:45Z"),
+			new:  status("observedAt", "2021-05-08T19:03:45.123456Z"),
+			want: true,
+		},
+		{
+			name: "Unstructured MicroTime Hint Precision",
+			opts: EqualOptions{ValueTypes: map[string]ValueType{"status.observedAt": MicroTimeValue}},
+			old:  status("observedAt", "2021-05-08T19:03:45Z"),
+			new:  status("observedAt", "2021-05-08T19:03:45.123456Z"),
+			want: false,
+		},
+		{
+			name: "Unstructured IntOrString Hint",
+			opts: EqualOptions{ValueTypes: map[string]ValueType{"status.port": IntOrStringValue}},
+			old:  status("port", int64(80)),
+			new:  status("port", "80"),
+			want: true,
+		},
+		{
+			name: "Unstructured Quantity Heuristic Number",
+			opts: EqualOptions{SemanticValues: true},
+			old:  status("ratio", 1.5),
+			new:  status("ratio", "1.50"),
+			want: true,
+		},
+		{
+			name: "Unstructured Heuristic Version",
+			opts: EqualOptions{SemanticValues: true},
+			old:  status("version", "1.20"),
+			new:  status("version", "1.2"),
+			want: false,
+		},
+		{
+			name: "Unstructured Heuristic Different",
+			opts: EqualOptions{SemanticValues: true},
+			old:  status("phase", "Running"),
+			new:  status("phase", "Pending"),
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.opts.StatusEqualE(tt.old, tt.new)
+			if err != nil {
+				t.Fatalf("StatusEqualE() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("StatusEqualE() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusCompare_SemanticVersion(t *testing.T) {
+	old := &unstructured.Unstructured{Object: map[string]interface{}{"status": map[string]interface{}{"version": "1.20"}}}
+	nu := &unstructured.Unstructured{Object: map[string]interface{}{"status": map[string]interface{}{"version": "1.2"}}}
+	diff, err := EqualOptions{SemanticValues: true}.StatusCompare(old, nu)
+	if err != nil {
+		t.Fatalf("StatusCompare() error = %v", err)
+	}
+	want := FieldDiff{Path: "status.version", Op: DiffChanged, Old: "1.20", New: "1.2"}
+	if diff.Equal() || diff.Fields[0] != want {
+		t.Errorf("StatusCompare() = %v, want %v", diff, want)
+	}
+}
+
+func TestStatusCompare_InvalidValueType(t *testing.T) {
+	opts := EqualOptions{ValueTypes: map[string]ValueType{"status.cpu": "Number"}}
+	if _, err := opts.StatusCompare(d1, d1); err == nil {
+		t.Errorf("StatusCompare() expected error for unknown value type")
+	}
+}
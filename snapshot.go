@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ObjectKey identifies an object of a snapshot.
+type ObjectKey struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+}
+
+// ObjectKeyFor returns the key of obj.
+func ObjectKeyFor(obj *unstructured.Unstructured) ObjectKey {
+	return ObjectKey{APIVersion: obj.GetAPIVersion(), Kind: obj.GetKind(), Namespace: obj.GetNamespace(), Name: obj.GetName()}
+}
+
+// String returns the key as apiVersion, kind and namespace/name, eg, apps/v1 Deployment demo/d1
+func (k ObjectKey) String() string {
+	name := k.Name
+	if k.Namespace != "" {
+		name = k.Namespace + "/" + k.Name
+	}
+	return fmt.Sprintf("%s %s %s", k.APIVersion, k.Kind, name)
+}
+
+// ObjectStatusDiff describes an object whose status differs between two snapshots.
+// Diff is only set for changed objects.
+type ObjectStatusDiff struct {
+	Object ObjectKey   `json:"object"`
+	Op     DiffOp      `json:"op"`
+	Diff   *StatusDiff `json:"diff,omitempty"`
+}
+
+// SnapshotDiff is the result of comparing two snapshots, eg, two backups of a cluster.
+type SnapshotDiff struct {
+	// Objects are sorted by key.
+	Objects []ObjectStatusDiff `json:"objects,omitempty"`
+	// Unchanged is the number of objects with equal status in both snapshots.
+	Unchanged int `json:"unchanged"`
+}
+
+func (d *SnapshotDiff) Equal() bool {
+	return d == nil || len(d.Objects) == 0
+}
+
+// OptionsFunc returns the policy used to compare the status of old and new, eg, Registry.OptionsFor
+type OptionsFunc func(old, new interface{}) (EqualOptions, error)
+
+// CompareSnapshots matches the objects of two snapshots by GVK, namespace and name
+// and returns the objects whose status changed, were added or were removed.
+// The status of each pair of objects is compared with the policy returned by optionsFor,
+// or with the default EqualOptions if optionsFor is nil.
+func CompareSnapshots(old, new []*unstructured.Unstructured, optionsFor OptionsFunc) (*SnapshotDiff, error) {
+	if optionsFor == nil {
+		optionsFor = func(_, _ interface{}) (EqualOptions, error) {
+			return EqualOptions{}, nil
+		}
+	}
+	oldObjects, err := indexSnapshot(old)
+	if err != nil {
+		return nil, err
+	}
+	newObjects, err := indexSnapshot(new)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SnapshotDiff{}
+	for key, o := range oldObjects {
+		n, ok := newObjects[key]
+		if !ok {
+			result.Objects = append(result.Objects, ObjectStatusDiff{Object: key, Op: DiffRemoved})
+			continue
+		}
+		opts, err := optionsFor(o, n)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", key, err)
+		}
+		diff, err := opts.StatusCompare(o, n)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", key, err)
+		}
+		if diff.Equal() {
+			result.Unchanged++
+			continue
+		}
+		result.Objects = append(result.Objects, ObjectStatusDiff{Object: key, Op: DiffChanged, Diff: diff})
+	}
+	for key := range newObjects {
+		if _, ok := oldObjects[key]; !ok {
+			result.Objects = append(result.Objects, ObjectStatusDiff{Object: key, Op: DiffAdded})
+		}
+	}
+	sort.Slice(result.Objects, func(i, j int) bool {
+		return result.Objects[i].Object.String() < result.Objects[j].Object.String()
+	})
+	return result, nil
+}
+
+// CompareSnapshots is like the package-level CompareSnapshots and compares
+// each object according to the policy of its kind.
+func (r *Registry) CompareSnapshots(old, new []*unstructured.Unstructured) (*SnapshotDiff, error) {
+	return CompareSnapshots(old, new, r.OptionsFor)
+}
+
+func indexSnapshot(objects []*unstructured.Unstructured) (map[ObjectKey]*unstructured.Unstructured, error) {
+	index := make(map[ObjectKey]*unstructured.Unstructured, len(objects))
+	for _, obj := range objects {
+		key := ObjectKeyFor(obj)
+		if _, ok := index[key]; ok {
+			return nil, fmt.Errorf("%w: %v", ErrDuplicateObject, key)
+		}
+		index[key] = obj
+	}
+	return index, nil
+}
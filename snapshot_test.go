@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestCompareSnapshots(t *testing.T) {
+	d1 := toJSON(a1).(*unstructured.Unstructured)
+	d1Ready := toJSON(a1ReplicasUpdated).(*unstructured.Unstructured)
+	d1Time := toJSON(a1ConditionTimeUpdated).(*unstructured.Unstructured)
+	d2 := named(d1, "d2")
+	d3 := named(d1, "d3")
+	other := named(d1, "d1")
+	other.SetNamespace("other")
+
+	tests := []struct {
+		name          string
+		old           []*unstructured.Unstructured
+		new           []*unstructured.Unstructured
+		want          map[string]DiffOp
+		wantUnchanged int
+		wantErr       error
+	}{
+		{
+			name:          "Equal",
+			old:           []*unstructured.Unstructured{d1, d2},
+			new:           []*unstructured.Unstructured{d2, d1Time},
+			want:          map[string]DiffOp{},
+			wantUnchanged: 2,
+		},
+		{
+			name: "Changed",
+			old:  []*unstructured.Unstructured{d1, d2},
+			new:  []*unstructured.Unstructured{d1Ready, d2},
+			want: map[string]DiffOp{
+				"apps/v1 Deployment demo/d1": DiffChanged,
+			},
+			wantUnchanged: 1,
+		},
+		{
+			name: "Added and Removed",
+			old:  []*unstructured.Unstructured{d1, d2},
+			new:  []*unstructured.Unstructured{d1, d3, other},
+			want: map[string]DiffOp{
+				"apps/v1 Deployment demo/d2":  DiffRemoved,
+				"apps/v1 Deployment demo/d3":  DiffAdded,
+				"apps/v1 Deployment other/d1": DiffAdded,
+			},
+			wantUnchanged: 1,
+		},
+		{
+			name:    "Duplicate",
+			old:     []*unstructured.Unstructured{d1, d1Time},
+			new:     []*unstructured.Unstructured{d1},
+			wantErr: ErrDuplicateObject,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewDefaultRegistry(nil).CompareSnapshots(tt.old, tt.new)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CompareSnapshots() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			ops := map[string]DiffOp{}
+			for _, o := range got.Objects {
+				ops[o.Object.String()] = o.Op
+				if (o.Op == DiffChanged) == o.Diff.Equal() {
+					t.Errorf("CompareSnapshots() %v %v diff = %v", o.Op, o.Object, o.Diff)
+				}
+			}
+			if !reflect.DeepEqual(ops, tt.want) {
+				t.Errorf("CompareSnapshots() = %v, want %v", ops, tt.want)
+			}
+			if got.Unchanged != tt.wantUnchanged {
+				t.Errorf("CompareSnapshots() unchanged = %v, want %v", got.Unchanged, tt.wantUnchanged)
+			}
+			if got.Equal() != (len(tt.want) == 0) {
+				t.Errorf("CompareSnapshots() equal = %v", got.Equal())
+			}
+		})
+	}
+}
+
+func TestDecodeManifests(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "Single",
+			data: a1,
+			want: []string{"d1"},
+		},
+		{
+			name: "Documents",
+			data: "---\n" + a1 + "\n---\n# empty\n---\n" + strings.Replace(a1, "name: d1", "name: d2", 1),
+			want: []string{"d1", "d2"},
+		},
+		{
+			name: "List",
+			data: "apiVersion: v1\nkind: List\nitems:\n- apiVersion: v1\n  kind: Pod\n  metadata:\n    name: p1\n- apiVersion: v1\n  kind: Pod\n  metadata:\n    name: p2\n",
+			want: []string{"p1", "p2"},
+		},
+		{
+			name: "Empty List",
+			data: "apiVersion: v1\nkind: List\nitems: []\n",
+			want: nil,
+		},
+		{
+			name:    "Invalid",
+			data:    "kind: [",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects, err := decodeManifests([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeManifests() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, obj := range objects {
+				got = append(got, obj.GetName())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeManifests() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
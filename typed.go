@@ -0,0 +1,368 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	quantityType      = reflect.TypeOf(resource.Quantity{})
+	intOrStringType   = reflect.TypeOf(intstr.IntOrString{})
+)
+
+// structInfo holds the json fields of a struct type, sorted by name.
+type structInfo struct {
+	fields []fieldInfo
+	// conditionLike is true if the struct has a type and a status field.
+	conditionLike bool
+}
+
+type fieldInfo struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// structInfos caches *structInfo per reflect.Type, so that every type is inspected once.
+var structInfos sync.Map
+
+func getStructInfo(t reflect.Type) *structInfo {
+	if info, ok := structInfos.Load(t); ok {
+		return info.(*structInfo)
+	}
+
+	info := &structInfo{fields: jsonFields(t, nil)}
+	sort.Slice(info.fields, func(i, j int) bool {
+		return info.fields[i].name < info.fields[j].name
+	})
+	var hasType, hasStatus bool
+	for _, f := range info.fields {
+		hasType = hasType || f.name == "type"
+		hasStatus = hasStatus || f.name == "status"
+	}
+	info.conditionLike = hasType && hasStatus
+
+	actual, _ := structInfos.LoadOrStore(t, info)
+	return actual.(*structInfo)
+}
+
+// jsonFields returns the fields of t as encoding/json sees them. Embedded structs
+// without a json name are inlined.
+func jsonFields(t reflect.Type, index []int) []fieldInfo {
+	var fields []fieldInfo
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if idx := strings.IndexByte(tag, ','); idx >= 0 {
+			name, opts = tag[:idx], tag[idx:]
+		}
+		fieldIndex := append(append([]int{}, index...), i)
+
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			fields = append(fields, jsonFields(f.Type, fieldIndex)...)
+			continue
+		}
+		if f.PkgPath != "" {
+			// unexported
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, fieldInfo{
+			name:      name,
+			index:     fieldIndex,
+			omitEmpty: strings.Contains(opts, ",omitempty"),
+		})
+	}
+	return fields
+}
+
+func sameStructType(old, nu interface{}) bool {
+	ot, nt := reflect.TypeOf(old), reflect.TypeOf(nu)
+	if ot != nt {
+		return false
+	}
+	if ot.Kind() == reflect.Ptr {
+		ot = ot.Elem()
+	}
+	return ot.Kind() == reflect.Struct
+}
+
+// compareTyped compares two values of the same Go type without converting them to maps.
+func (d *differ) compareTyped(path string, old, nu reflect.Value) {
+	if d.opts.EmptyEquivalence != EmptyStrict && d.opts.equivalentToMissing(old.Interface()) && d.opts.equivalentToMissing(nu.Interface()) {
+		return
+	}
+	for old.Kind() == reflect.Ptr || old.Kind() == reflect.Interface {
+		if old.IsNil() || nu.IsNil() {
+			if old.IsNil() != nu.IsNil() {
+				d.add(FieldDiff{Path: path, Op: DiffChanged, Old: old.Interface(), New: nu.Interface()})
+			}
+			return
+		}
+		if old.Kind() == reflect.Interface && old.Elem().Type() != nu.Elem().Type() {
+			d.add(FieldDiff{Path: path, Op: DiffChanged, Old: old.Interface(), New: nu.Interface()})
+			return
+		}
+		old, nu = old.Elem(), nu.Elem()
+	}
+
+	t := old.Type()
+	if t.Kind() == reflect.Slice && (d.conditions.MatchExact(path) || (d.opts.DetectConditions && isConditionSliceType(t))) {
+		if old.IsNil() != nu.IsNil() {
+			d.add(FieldDiff{Path: path, Op: DiffChanged, Old: old.Interface(), New: nu.Interface()})
+			return
+		}
+		d.compareConditionLists(path, typedConditions(old), typedConditions(nu))
+		return
+	}
+	if t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType) {
+		d.compareLeaf(path, old, nu)
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		for _, f := range getStructInfo(t).fields {
+			p := path + "." + f.name
+			if d.ignore.Match(p) {
+				continue
+			}
+			ov, nv := old.FieldByIndex(f.index), nu.FieldByIndex(f.index)
+			if f.omitEmpty {
+				oldEmpty, newEmpty := isEmptyValue(ov), isEmptyValue(nv)
+				switch {
+				case oldEmpty && newEmpty:
+					continue
+				case oldEmpty:
+					d.add(FieldDiff{Path: p, Op: DiffAdded, New: nv.Interface()})
+					continue
+				case newEmpty:
+					d.add(FieldDiff{Path: p, Op: DiffRemoved, Old: ov.Interface()})
+					continue
+				}
+			}
+			d.compareTyped(p, ov, nv)
+		}
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			d.compareLeaf(path, old, nu)
+			return
+		}
+		keys := make(map[string]reflect.Value, old.Len()+nu.Len())
+		for _, m := range []reflect.Value{old, nu} {
+			for _, k := range m.MapKeys() {
+				keys[k.String()] = k
+			}
+		}
+		names := make([]string, 0, len(keys))
+		for name := range keys {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			p := path + "." + name
+			if d.ignore.Match(p) {
+				continue
+			}
+			ov, nv := old.MapIndex(keys[name]), nu.MapIndex(keys[name])
+			switch {
+			case !ov.IsValid() && d.opts.equivalentToMissing(nv.Interface()), !nv.IsValid() && d.opts.equivalentToMissing(ov.Interface()):
+			case !ov.IsValid():
+				d.add(FieldDiff{Path: p, Op: DiffAdded, New: nv.Interface()})
+			case !nv.IsValid():
+				d.add(FieldDiff{Path: p, Op: DiffRemoved, Old: ov.Interface()})
+			default:
+				d.compareTyped(p, ov, nv)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if keys := d.listMapKeys(path); keys != nil && d.compareTypedListMap(path, keys, old, nu) {
+			return
+		}
+		if d.listSets.MatchExact(path) {
+			d.compareListSet(path, typedListItems(old), typedListItems(nu))
+			return
+		}
+		if old.Len() != nu.Len() || !isCompositeKind(t.Elem()) {
+			d.compareLeaf(path, old, nu)
+			return
+		}
+		for i := 0; i < old.Len(); i++ {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			if d.ignore.Match(p) {
+				continue
+			}
+			d.compareTyped(p, old.Index(i), nu.Index(i))
+		}
+	default:
+		d.compareLeaf(path, old, nu)
+	}
+}
+
+func (d *differ) compareLeaf(path string, old, nu reflect.Value) {
+	if ov, nv := old.Interface(), nu.Interface(); !d.valuesEqual(path, ov, nv) {
+		d.add(FieldDiff{Path: path, Op: DiffChanged, Old: ov, New: nv})
+	}
+}
+
+func isCompositeKind(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Interface:
+		return true
+	}
+	return false
+}
+
+func isConditionSliceType(t reflect.Type) bool {
+	elem := t.Elem()
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	return elem.Kind() == reflect.Struct && getStructInfo(elem).conditionLike
+}
+
+// typedConditions converts a typed condition list into maps keyed by json field name.
+// Field values are kept as is and fields omitted by encoding/json are skipped.
+func typedConditions(list reflect.Value) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		item := list.Index(i)
+		for item.Kind() == reflect.Ptr || item.Kind() == reflect.Interface {
+			item = item.Elem()
+		}
+		if item.Kind() != reflect.Struct {
+			continue
+		}
+		fields := getStructInfo(item.Type()).fields
+		c := make(map[string]interface{}, len(fields))
+		for _, f := range fields {
+			v := item.FieldByIndex(f.index)
+			if f.omitEmpty && isEmptyValue(v) {
+				continue
+			}
+			c[f.name] = v.Interface()
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// isEmptyValue reports whether v is omitted by encoding/json with omitempty.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// statusGoType returns the Go type of the status of obj, ie, the type of a typed status or
+// the type of the Status field of the kind of an unstructured object in scheme.
+// It returns nil if the type is unknown.
+func statusGoType(obj, status interface{}, scheme *runtime.Scheme) reflect.Type {
+	if status == nil {
+		return nil
+	}
+	if _, ok := status.(map[string]interface{}); !ok {
+		return reflect.TypeOf(status)
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok || u.GroupVersionKind().Empty() {
+		return nil
+	}
+	typed, err := scheme.New(u.GroupVersionKind())
+	if err != nil {
+		return nil
+	}
+	t := reflect.TypeOf(typed)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	if f, ok := t.FieldByName("Status"); ok {
+		return f.Type
+	}
+	return nil
+}
+
+// typedValueTypes caches the value types of status Go types per reflect.Type.
+var typedValueTypes sync.Map
+
+// valueTypesOf returns the value types of the quantity and int-or-string fields of the
+// status Go type t by path, eg, status.capacity.* is a QuantityValue for core.NodeStatus.
+// Condition lists are left out, as they are compared by their own rules.
+func valueTypesOf(t reflect.Type) []valueTypeMatcher {
+	if m, ok := typedValueTypes.Load(t); ok {
+		return m.([]valueTypeMatcher)
+	}
+	types := map[string]ValueType{}
+	collectValueTypes("status", t, types, map[reflect.Type]bool{})
+	// the generated paths and types are always valid
+	m, _ := newValueTypeMatchers(types)
+	actual, _ := typedValueTypes.LoadOrStore(t, m)
+	return actual.([]valueTypeMatcher)
+}
+
+func collectValueTypes(path string, t reflect.Type, out map[string]ValueType, visiting map[reflect.Type]bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case quantityType:
+		out[path] = QuantityValue
+		return
+	case intOrStringType:
+		out[path] = IntOrStringValue
+		return
+	}
+	if visiting[t] || t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType) {
+		return
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		visiting[t] = true
+		for _, f := range getStructInfo(t).fields {
+			collectValueTypes(path+"."+f.name, t.FieldByIndex(f.index).Type, out, visiting)
+		}
+		delete(visiting, t)
+	case reflect.Map:
+		if t.Key().Kind() == reflect.String {
+			collectValueTypes(path+".*", t.Elem(), out, visiting)
+		}
+	case reflect.Slice, reflect.Array:
+		if !isConditionSliceType(t) {
+			collectValueTypes(path+"[*]", t.Elem(), out, visiting)
+		}
+	}
+}
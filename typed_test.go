@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fatih/structs"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type largeMember struct {
+	Name       string             `json:"name"`
+	Role       string             `json:"role,omitempty"`
+	Ready      bool               `json:"ready"`
+	Lag        int64              `json:"lag,omitempty"`
+	Endpoints  []string           `json:"endpoints,omitempty"`
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+type largeStatus struct {
+	ObservedGeneration int64              `json:"observedGeneration,omitempty"`
+	Phase              string             `json:"phase,omitempty"`
+	Members            []largeMember      `json:"members,omitempty"`
+	Stats              map[string]int64   `json:"stats,omitempty"`
+	Conditions         []metav1.Condition `json:"conditions,omitempty"`
+}
+
+type largeObject struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Status            largeStatus `json:"status,omitempty"`
+}
+
+func newLargeObject(members int, transition metav1.Time) *largeObject {
+	obj := &largeObject{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "db.example.com/v1",
+			Kind:       "Database",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "demo",
+			Name:      "db",
+		},
+		Status: largeStatus{
+			ObservedGeneration: 4,
+			Phase:              "Ready",
+			Stats:              map[string]int64{},
+		},
+	}
+	for i := 0; i < members; i++ {
+		name := fmt.Sprintf("db-%d", i)
+		obj.Status.Members = append(obj.Status.Members, largeMember{
+			Name:      name,
+			Role:      "replica",
+			Ready:     true,
+			Endpoints: []string{name + ".demo.svc:5432"},
+			Conditions: []metav1.Condition{
+				{Type: "Ready", Status: metav1.ConditionTrue, LastTransitionTime: transition, Reason: "Running"},
+			},
+		})
+		obj.Status.Stats[name] = int64(i)
+	}
+	obj.Status.Conditions = []metav1.Condition{
+		{Type: "Ready", Status: metav1.ConditionTrue, LastTransitionTime: transition, Reason: "AllMembersReady"},
+	}
+	return obj
+}
+
+func toUnstructured(obj interface{}) *unstructured.Unstructured {
+	m, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		panic(err)
+	}
+	return &unstructured.Unstructured{Object: m}
+}
+
+func TestStatusCompare_Typed(t *testing.T) {
+	opts := EqualOptions{
+		ConditionPaths: []string{"status.conditions", "status.members[*].conditions"},
+	}
+	old := newLargeObject(3, metav1.Unix(1620500625, 0))
+	nu := newLargeObject(3, metav1.Unix(1620501081, 0))
+	if !opts.StatusEqual(old, nu) {
+		t.Errorf("StatusEqual() = false, want true")
+	}
+
+	nu.Status.Members[1].Conditions[0].Status = metav1.ConditionFalse
+	nu.Status.Members[2].Lag = 7
+	got, err := opts.StatusCompare(old, nu)
+	if err != nil {
+		t.Fatalf("StatusCompare() error = %v", err)
+	}
+	want := []string{"status.members[1].conditions[type=Ready].status", "status.members[2].lag"}
+	if len(got.Fields) != len(want) {
+		t.Fatalf("StatusCompare() = %v, want paths %v", got, want)
+	}
+	for i, f := range got.Fields {
+		if f.Path != want[i] {
+			t.Errorf("StatusCompare() path = %v, want %v", f.Path, want[i])
+		}
+	}
+	if got.Fields[1].Op != DiffAdded {
+		t.Errorf("StatusCompare() op = %v, want %v", got.Fields[1].Op, DiffAdded)
+	}
+
+	// same result as the unstructured comparison
+	u, err := opts.StatusCompare(toUnstructured(old), toUnstructured(nu))
+	if err != nil {
+		t.Fatalf("StatusCompare() error = %v", err)
+	}
+	for i, f := range u.Fields {
+		if f.Path != want[i] {
+			t.Errorf("StatusCompare() unstructured path = %v, want %v", f.Path, want[i])
+		}
+	}
+}
+
+func BenchmarkStatusEqual(b *testing.B) {
+	opts := EqualOptions{
+		ConditionPaths: []string{"status.conditions", "status.members[*].conditions"},
+	}
+	largeOld := newLargeObject(500, metav1.Unix(1620500625, 0))
+	largeNew := newLargeObject(500, metav1.Unix(1620501081, 0))
+
+	benchmarks := []struct {
+		name string
+		old  interface{}
+		new  interface{}
+	}{
+		{name: "Deployment/Typed", old: d1, new: d1ConditionTimeUpdated},
+		{name: "Deployment/Unstructured", old: toUnstructured(d1), new: toUnstructured(d1ConditionTimeUpdated)},
+		{name: "LargeCRD/Typed", old: largeOld, new: largeNew},
+		{name: "LargeCRD/Unstructured", old: toUnstructured(largeOld), new: toUnstructured(largeNew)},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if !opts.StatusEqual(bm.old, bm.new) {
+					b.Fatal("StatusEqual() = false, want true")
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkStatusEqual_StructsMap measures the typed comparison through fatih/structs
+// maps, as done before the typed fast path.
+func BenchmarkStatusEqual_StructsMap(b *testing.B) {
+	opts := EqualOptions{
+		ConditionPaths: []string{"status.conditions", "status.members[*].conditions"},
+	}
+	largeOld := newLargeObject(500, metav1.Unix(1620500625, 0))
+	largeNew := newLargeObject(500, metav1.Unix(1620501081, 0))
+
+	benchmarks := []struct {
+		name string
+		old  interface{}
+		new  interface{}
+	}{
+		{name: "Deployment", old: d1.Status, new: d1ConditionTimeUpdated.Status},
+		{name: "LargeCRD", old: largeOld.Status, new: largeNew.Status},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				d := differ{opts: opts}
+				d.conditions, _ = newPathMatcher(opts.ConditionPaths)
+				oldMap := structsMap(bm.old)
+				newMap := structsMap(bm.new)
+				if err := d.compareMaps("status", oldMap, newMap); err != nil || len(d.fields) > 0 {
+					b.Fatal("compareMaps() found differences")
+				}
+			}
+		})
+	}
+}
+
+func structsMap(in interface{}) map[string]interface{} {
+	st := structs.New(in)
+	st.TagName = "json"
+	return st.Map()
+}
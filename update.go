@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// StatusUpdateNeeded is like EqualOptions.StatusUpdateNeeded using the default EqualOptions.
+func StatusUpdateNeeded(current, desired interface{}) (bool, error) {
+	return EqualOptions{}.StatusUpdateNeeded(current, desired)
+}
+
+// StatusUpdateNeeded reports whether the status of desired, eg, a modified copy of current,
+// differs from the status of current, ie, whether it has to be written with UpdateStatus.
+// If it does, the conditions of desired whose status didn't change keep the
+// lastTransitionTime of the matching condition of current, so that rebuilding the conditions
+// in every reconcile doesn't move their transition time. Desired is modified in place and
+// must be a pointer for typed objects.
+// Only the condition lists at ConditionPaths without wildcards are updated.
+func (opts EqualOptions) StatusUpdateNeeded(current, desired interface{}) (bool, error) {
+	equal, err := opts.StatusEqualE(current, desired)
+	if err != nil {
+		return false, err
+	}
+	if equal {
+		return false, nil
+	}
+	if err := opts.preserveTransitionTimes(current, desired); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+func (opts EqualOptions) preserveTransitionTimes(current, desired interface{}) error {
+	currentStatus, ok, err := extractStatusFromObject(current)
+	if err != nil || !ok || statusKind(currentStatus) != "object" {
+		return err
+	}
+	currentMap, err := statusToMap(currentStatus)
+	if err != nil {
+		return err
+	}
+	desiredStatus, ok := statusValue(desired)
+	if !ok {
+		return nil
+	}
+
+	conditionPaths := opts.ConditionPaths
+	if len(conditionPaths) == 0 {
+		conditionPaths = DefaultConditionPaths
+	}
+	for _, path := range conditionPaths {
+		fields, ok := plainFieldPath(path)
+		if !ok {
+			continue
+		}
+		existing, found, err := unstructured.NestedFieldNoCopy(currentMap, fields...)
+		if err != nil || !found {
+			continue
+		}
+		list, ok := lookupField(desiredStatus, fields)
+		if !ok {
+			continue
+		}
+		if err := mergeTransitionTimes(existing, list, nil); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrStatusDecode, path, err)
+		}
+	}
+	return nil
+}
+
+// statusValue returns the status of obj as a reflect.Value whose maps, slices and, for
+// pointers to typed objects, fields can be modified.
+func statusValue(obj interface{}) (reflect.Value, bool) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		if u == nil {
+			return reflect.Value{}, false
+		}
+		v, ok, _ := unstructured.NestedFieldNoCopy(u.Object, "status")
+		return reflect.ValueOf(v), ok && v != nil
+	}
+	v := reflect.ValueOf(obj)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+	status := v.FieldByName("Status")
+	return status, status.IsValid()
+}
+
+// plainFieldPath returns the fields of a status path below status, eg, [conditions]
+// for status.conditions. ok is false if the path has list or wildcard segments.
+func plainFieldPath(path string) ([]string, bool) {
+	segments, err := pathSegments(path)
+	if err != nil || len(segments) < 2 || segments[0] != "status" {
+		return nil, false
+	}
+	for _, s := range segments[1:] {
+		if s == "*" || strings.HasPrefix(s, "[") {
+			return nil, false
+		}
+	}
+	return segments[1:], true
+}
+
+// lookupField returns the value at the json field path in a typed or unstructured value.
+func lookupField(v reflect.Value, fields []string) (reflect.Value, bool) {
+	for _, name := range fields {
+		v = indirect(v)
+		switch v.Kind() {
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				return reflect.Value{}, false
+			}
+			v = v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+			if !v.IsValid() {
+				return reflect.Value{}, false
+			}
+		case reflect.Struct:
+			f, ok := jsonField(v.Type(), name)
+			if !ok {
+				return reflect.Value{}, false
+			}
+			v = v.FieldByIndex(f.index)
+		default:
+			return reflect.Value{}, false
+		}
+	}
+	return indirect(v), true
+}
+
+func jsonField(t reflect.Type, name string) (fieldInfo, bool) {
+	for _, f := range getStructInfo(t).fields {
+		if f.name == name {
+			return f, true
+		}
+	}
+	return fieldInfo{}, false
+}
+
+// indirect dereferences pointers and interfaces, stopping at nil.
+func indirect(v reflect.Value) reflect.Value {
+	for (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestStatusUpdateNeeded_Typed(t *testing.T) {
+	then := metav1.NewTime(time.Date(2021, 5, 8, 19, 3, 45, 0, time.UTC))
+	now := metav1.NewTime(then.Add(time.Hour))
+	deployment := func(replicas int32, progressing core.ConditionStatus, transition metav1.Time) *apps.Deployment {
+		return &apps.Deployment{
+			Status: apps.DeploymentStatus{
+				ReadyReplicas: replicas,
+				Conditions: []apps.DeploymentCondition{
+					{Type: apps.DeploymentAvailable, Status: core.ConditionTrue, LastTransitionTime: transition},
+					{Type: apps.DeploymentProgressing, Status: progressing, LastTransitionTime: transition},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		desired   *apps.Deployment
+		want      bool
+		wantTimes []metav1.Time
+	}{
+		{
+			name:      "Equal",
+			desired:   deployment(3, core.ConditionTrue, now),
+			want:      false,
+			wantTimes: []metav1.Time{now, now},
+		},
+		{
+			name:      "Replicas Updated",
+			desired:   deployment(2, core.ConditionTrue, now),
+			want:      true,
+			wantTimes: []metav1.Time{then, then},
+		},
+		{
+			name:      "Condition Status Updated",
+			desired:   deployment(3, core.ConditionFalse, now),
+			want:      true,
+			wantTimes: []metav1.Time{then, now},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := deployment(3, core.ConditionTrue, then)
+			got, err := StatusUpdateNeeded(current, tt.desired)
+			if err != nil {
+				t.Fatalf("StatusUpdateNeeded() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("StatusUpdateNeeded() = %v, want %v", got, tt.want)
+			}
+			for i, c := range tt.desired.Status.Conditions {
+				if !c.LastTransitionTime.Equal(&tt.wantTimes[i]) {
+					t.Errorf("condition %s lastTransitionTime = %v, want %v", c.Type, c.LastTransitionTime, tt.wantTimes[i])
+				}
+			}
+		})
+	}
+}
+
+func TestStatusUpdateNeeded_Unstructured(t *testing.T) {
+	object := func(phase, ready, transition string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"status": map[string]interface{}{
+				"phase": phase,
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Ready", "status": ready, "lastTransitionTime": transition},
+				},
+			},
+		}}
+	}
+	transition := func(u *unstructured.Unstructured) interface{} {
+		conditions, _, _ := unstructured.NestedSlice(u.Object, "status", "conditions")
+		return conditions[0].(map[string]interface{})["lastTransitionTime"]
+	}
+
+	current := object("Running", "True", "2021-05-08T19:03:45Z")
+	desired := object("Upgrading", "True", "2021-05-08T20:03:45Z")
+	got, err := StatusUpdateNeeded(current, desired)
+	if err != nil {
+		t.Fatalf("StatusUpdateNeeded() error = %v", err)
+	}
+	if !got {
+		t.Errorf("StatusUpdateNeeded() = false, want true")
+	}
+	if want := "2021-05-08T19:03:45Z"; transition(desired) != want {
+		t.Errorf("lastTransitionTime = %v, want %v", transition(desired), want)
+	}
+
+	// conditions whose status changed keep the desired transition time
+	desired = object("Upgrading", "False", "2021-05-08T20:03:45Z")
+	if _, err := StatusUpdateNeeded(current, desired); err != nil {
+		t.Fatalf("StatusUpdateNeeded() error = %v", err)
+	}
+	if want := "2021-05-08T20:03:45Z"; transition(desired) != want {
+		t.Errorf("lastTransitionTime = %v, want %v", transition(desired), want)
+	}
+}
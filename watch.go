@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// watchFlags are the flags of the watch command.
+type watchFlags struct {
+	allNamespaces     bool
+	selector          string
+	flapping          bool
+	flappingWindow    time.Duration
+	flappingThreshold int
+}
+
+func (c *cli) addWatchFlags(fs *pflag.FlagSet) {
+	c.addOptionFlags(fs)
+	fs.BoolVarP(&c.watchFlags.allNamespaces, "all-namespaces", "A", false, "Watch the objects of every namespace")
+	fs.StringVarP(&c.watchFlags.selector, "selector", "l", "", "Label selector of the watched objects, eg, app=nginx")
+	fs.BoolVar(&c.watchFlags.flapping, "flapping", false, "Print the objects and conditions that are flapping instead of every update")
+	fs.DurationVar(&c.watchFlags.flappingWindow, "flapping-window", DefaultFlappingWindow, "Sliding window of the transitions counted by --flapping")
+	fs.IntVar(&c.watchFlags.flappingThreshold, "flapping-threshold", DefaultFlappingThreshold, "Number of transitions within --flapping-window above which an object or condition is flapping")
+}
+
+// WatchEvent is the json and yaml output of the watch command.
+type WatchEvent struct {
+	Time        metav1.Time `json:"time"`
+	Object      ObjectKey   `json:"object"`
+	*StatusDiff `json:",inline"`
+}
+
+func (c *cli) watch(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("watch takes 1 resource, got %d", len(args))
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := c.startWatch(ctx, args[0]); err != nil {
+		return err
+	}
+	<-ctx.Done()
+	return nil
+}
+
+// startWatch starts an informer for a RESOURCE or RESOURCE/NAME argument that prints
+// the updates with different status, and waits until its cache is synced.
+func (c *cli) startWatch(ctx context.Context, arg string) error {
+	parts := strings.SplitN(arg, "/", 2)
+	if parts[0] == "" || (len(parts) == 2 && parts[1] == "") {
+		return fmt.Errorf("invalid resource %q, expected RESOURCE or RESOURCE/NAME", arg)
+	}
+	_, mapping, err := c.resource(parts[0])
+	if err != nil {
+		return err
+	}
+	// validate the flags before anything is watched
+	switch c.output {
+	case "text", "", "json", "yaml":
+	default:
+		return fmt.Errorf("unknown output format %q, expected text, json or yaml", c.output)
+	}
+	if _, err := c.overrides.apply(EqualOptions{}); err != nil {
+		return err
+	}
+	if _, err := c.policies(); err != nil {
+		return err
+	}
+
+	ns := metav1.NamespaceAll
+	if !c.watchFlags.allNamespaces && mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		ns = c.namespace
+		if ns == "" {
+			ns = c.defaultNamespace
+		}
+	}
+	ri := c.client.Resource(mapping.Resource)
+	tweak := func(options *metav1.ListOptions) {
+		options.LabelSelector = c.watchFlags.selector
+		if len(parts) == 2 {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", parts[1]).String()
+		}
+	}
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			tweak(&options)
+			return ri.Namespace(ns).List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			tweak(&options)
+			return ri.Namespace(ns).Watch(ctx, options)
+		},
+	}
+	informer := cache.NewSharedInformer(lw, &unstructured.Unstructured{}, 0)
+	if c.watchFlags.flapping {
+		detector := NewFlappingDetector(c.watchFlags.flappingWindow, c.watchFlags.flappingThreshold, c.options)
+		detector.Clock = c.clock
+		informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				c.printFlapping(detector, oldObj, newObj)
+			},
+			DeleteFunc: func(obj interface{}) {
+				if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+					obj = tombstone.Obj
+				}
+				detector.Forget(obj)
+			},
+		})
+	} else {
+		informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			UpdateFunc: c.printUpdate,
+		})
+	}
+	go informer.Run(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return fmt.Errorf("could not sync the cache of %v", mapping.Resource)
+	}
+	return nil
+}
+
+// printUpdate prints the diff of an update if the status of the objects is different.
+func (c *cli) printUpdate(oldObj, newObj interface{}) {
+	old, ok := oldObj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+	nu, ok := newObj.(*unstructured.Unstructured)
+	if !ok || isResync(old, nu) {
+		return
+	}
+	opts, err := c.options(old, nu)
+	if err != nil {
+		klog.Errorln(err)
+		return
+	}
+	diff, err := opts.StatusCompare(old, nu)
+	if err != nil {
+		klog.Errorln(err)
+		return
+	}
+	if diff.Equal() {
+		return
+	}
+	event := WatchEvent{Time: metav1.NewTime(c.now()), Object: ObjectKeyFor(nu), StatusDiff: diff}
+	if c.output == "yaml" {
+		fmt.Fprintln(c.stdout, "---")
+	}
+	err = c.print(event, func(w io.Writer) {
+		fmt.Fprintf(w, "%s %s\n", event.Time.Format(time.RFC3339), event.Object)
+		printIndented(w, diff)
+	})
+	if err != nil {
+		klog.Errorln(err)
+	}
+}
+
+// printFlapping records an update in detector and prints the flapping status and
+// conditions of the object.
+func (c *cli) printFlapping(detector *FlappingDetector, oldObj, newObj interface{}) {
+	if isResync(oldObj, newObj) {
+		return
+	}
+	flapping, err := detector.Observe(oldObj, newObj)
+	if err != nil {
+		klog.Errorln(err)
+		return
+	}
+	for _, f := range flapping {
+		if c.output == "yaml" {
+			fmt.Fprintln(c.stdout, "---")
+		}
+		err := c.print(f, func(w io.Writer) {
+			fmt.Fprintf(w, "%s flapping %s\n", c.now().UTC().Format(time.RFC3339), f)
+		})
+		if err != nil {
+			klog.Errorln(err)
+		}
+	}
+}
+
+// isResync reports whether an update is a resync of the informer, ie, the objects
+// have the same resourceVersion.
+func isResync(oldObj, newObj interface{}) bool {
+	old, err := meta.Accessor(oldObj)
+	if err != nil {
+		return false
+	}
+	nu, err := meta.Accessor(newObj)
+	if err != nil {
+		return false
+	}
+	return old.GetResourceVersion() == nu.GetResourceVersion()
+}
+
+func (c *cli) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+	return c.clock.Now()
+}
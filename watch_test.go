@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/wait"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+// syncBuffer is a bytes.Buffer that is written by informer goroutines.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+var deployments = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+func newTestWatchCLI(objects ...runtime.Object) (*cli, *syncBuffer) {
+	c, _, _ := newTestCLI()
+	// the fake client can only list unstructured objects whose kind is not in its scheme
+	c.client = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		deployments: "DeploymentList",
+	}, objects...)
+	var stdout syncBuffer
+	c.stdout = &stdout
+	c.clock = clock.NewFakePassiveClock(time.Date(2021, 5, 8, 19, 3, 45, 0, time.UTC))
+	return c, &stdout
+}
+
+// updateAll updates the objects in order, with increasing resourceVersions.
+func updateAll(ctx context.Context, t *testing.T, c *cli, manifests ...string) {
+	ri := c.client.Resource(deployments).Namespace("demo")
+	for i, s := range manifests {
+		obj := named(toJSON(s), "d1")
+		obj.SetResourceVersion(strconv.Itoa(i + 2))
+		if _, err := ri.Update(ctx, obj, metav1.UpdateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// waitForOutput waits until the informer printed the given number of lines.
+func waitForOutput(t *testing.T, stdout *syncBuffer, lines int) {
+	err := wait.PollImmediate(10*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		return strings.Count(stdout.String(), "\n") >= lines, nil
+	})
+	if err != nil {
+		t.Fatalf("watch output = %q, want %d lines", stdout, lines)
+	}
+}
+
+func TestCLI_Watch(t *testing.T) {
+	c, stdout := newTestWatchCLI(named(toJSON(a1), "d1"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := c.startWatch(ctx, "deployments"); err != nil {
+		t.Fatalf("startWatch() error = %v", err)
+	}
+	updateAll(ctx, t, c, a1ConditionTimeUpdated, a1ReplicasUpdated)
+
+	want := `2021-05-08T19:03:45Z apps/v1 Deployment demo/d1
+  status.availableReplicas: 3 -> 2
+  status.readyReplicas: 3 -> 2
+`
+	waitForOutput(t, stdout, 3)
+	if got := stdout.String(); got != want {
+		t.Errorf("watch output = %q, want %q", got, want)
+	}
+}
+
+func TestCLI_WatchFlapping(t *testing.T) {
+	c, stdout := newTestWatchCLI(named(toJSON(a1), "d1"))
+	c.watchFlags = watchFlags{flapping: true, flappingWindow: time.Minute, flappingThreshold: 2}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := c.startWatch(ctx, "deployments"); err != nil {
+		t.Fatalf("startWatch() error = %v", err)
+	}
+	updateAll(ctx, t, c, a1ConditionStatusUpdated, a1, a1ConditionTimeUpdated, a1ConditionStatusUpdated)
+
+	want := `2021-05-08T19:03:45Z flapping apps/v1 Deployment demo/d1 status: 3 transitions since 2021-05-08T19:03:45Z
+2021-05-08T19:03:45Z flapping apps/v1 Deployment demo/d1 status.conditions[type=Progressing]: 3 transitions since 2021-05-08T19:03:45Z
+`
+	waitForOutput(t, stdout, 2)
+	if got := stdout.String(); got != want {
+		t.Errorf("watch output = %q, want %q", got, want)
+	}
+}
+
+func TestCLI_WatchInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{
+			name: "Missing Resource",
+			args: []string{"watch"},
+		},
+		{
+			name: "Missing Name",
+			args: []string{"watch", "deployments/"},
+		},
+		{
+			name: "Unknown Resource",
+			args: []string{"watch", "widgets"},
+		},
+		{
+			name: "Invalid Output",
+			args: []string{"watch", "-o", "wide", "deployments"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _, _ := newTestCLI()
+			if code := c.run(tt.args); code != exitError {
+				t.Errorf("run() = %v, want %v", code, exitError)
+			}
+		})
+	}
+}